@@ -3,17 +3,37 @@
 package polygen
 
 import (
+	"archive/zip"
+	"bufio"
 	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/binary"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"iter"
+	"maps"
 	"math"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 )
 
 // ============ Validation ============
@@ -26,6 +46,8 @@ const (
 	SeverityError ValidationSeverity = iota
 	// SeverityWarning indicates a non-critical validation issue.
 	SeverityWarning
+	// SeverityInfo indicates an informational note that is neither a failure nor a warning.
+	SeverityInfo
 )
 
 func (s ValidationSeverity) String() string {
@@ -34,28 +56,76 @@ func (s ValidationSeverity) String() string {
 		return "Error"
 	case SeverityWarning:
 		return "Warning"
+	case SeverityInfo:
+		return "Info"
 	default:
 		return "Unknown"
 	}
 }
 
+// MarshalText encodes the severity by name so JSON output stays readable.
+func (s ValidationSeverity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name produced by MarshalText.
+func (s *ValidationSeverity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "Error":
+		*s = SeverityError
+	case "Warning":
+		*s = SeverityWarning
+	case "Info":
+		*s = SeverityInfo
+	default:
+		return fmt.Errorf("unknown validation severity %q", text)
+	}
+	return nil
+}
+
 // ValidationError represents a single validation failure.
 type ValidationError struct {
-	TableName      string
-	FieldName      string
-	RowKey         string
-	Message        string
-	Severity       ValidationSeverity
-	ConstraintType string
+	TableName      string             `json:"table_name"`
+	FieldName      string             `json:"field_name"`
+	RowKey         string             `json:"row_key"`
+	Message        string             `json:"message"`
+	Severity       ValidationSeverity `json:"severity"`
+	ConstraintType string             `json:"constraint_type"`
+	// Code is a stable machine-readable error ID such as "MAX_LENGTH", for lookups like localisation.
+	Code string `json:"code,omitempty"`
+	// LineNumber is the 1-based physical line in the source CSV file, or 0 when unknown.
+	LineNumber int `json:"line_number,omitempty"`
+}
+
+// WithLineNumber returns a copy of e with LineNumber set.
+func (e ValidationError) WithLineNumber(lineNumber int) ValidationError {
+	e.LineNumber = lineNumber
+	return e
+}
+
+// WithCsvRow returns a copy of e located at the physical line where row starts.
+func (e ValidationError) WithCsvRow(row *CsvRow) ValidationError {
+	e.LineNumber = row.line
+	return e
 }
 
 func (e ValidationError) String() string {
-	return fmt.Sprintf("[%s] %s.%s (row %s): %s", e.Severity, e.TableName, e.FieldName, e.RowKey, e.Message)
+	location := "row " + e.RowKey
+	if e.LineNumber > 0 {
+		location += fmt.Sprintf(", line %d", e.LineNumber)
+	}
+	return fmt.Sprintf("[%s] %s.%s (%s): %s", e.Severity, e.TableName, e.FieldName, location, e.Message)
 }
 
 // ValidationResult collects validation errors.
 type ValidationResult struct {
 	Errors []ValidationError
+	// maxErrors caps how many errors are stored; 0 means unlimited.
+	maxErrors  int
+	suppressed int
+	// suppressedWarnings and suppressedInfo count those severities among the suppressed entries.
+	suppressedWarnings int
+	suppressedInfo     int
 }
 
 // NewValidationResult creates a new empty validation result.
@@ -63,43 +133,356 @@ func NewValidationResult() *ValidationResult {
 	return &ValidationResult{Errors: make([]ValidationError, 0)}
 }
 
+// NewValidationResultCapped creates a validation result that stores at most max errors.
+// Further errors are only counted, keeping pathological inputs from exhausting memory.
+func NewValidationResultCapped(max int) *ValidationResult {
+	r := NewValidationResult()
+	if max > 0 {
+		r.maxErrors = max
+	}
+	return r
+}
+
 // AddError adds a validation error to the result.
 func (r *ValidationResult) AddError(err ValidationError) {
+	if r.maxErrors > 0 && len(r.Errors) >= r.maxErrors {
+		r.suppress(err)
+		return
+	}
 	r.Errors = append(r.Errors, err)
 }
 
-// IsValid returns true if there are no errors.
+// SetMaxErrors caps how many errors are stored; n <= 0 removes the cap.
+// Stored errors beyond a lowered cap are dropped and counted as suppressed.
+func (r *ValidationResult) SetMaxErrors(n int) {
+	if n <= 0 {
+		r.maxErrors = 0
+		return
+	}
+	r.maxErrors = n
+	if len(r.Errors) > n {
+		for _, err := range r.Errors[n:] {
+			r.suppress(err)
+		}
+		r.Errors = r.Errors[:n]
+	}
+}
+
+// IsTruncated reports whether any errors were dropped because of the cap.
+func (r *ValidationResult) IsTruncated() bool {
+	return r.suppressed > 0
+}
+
+func (r *ValidationResult) suppress(err ValidationError) {
+	r.suppressed++
+	switch err.Severity {
+	case SeverityWarning:
+		r.suppressedWarnings++
+	case SeverityInfo:
+		r.suppressedInfo++
+	}
+}
+
+// IsValid returns true if there are no error-severity entries; warnings and info are ignored.
 func (r *ValidationResult) IsValid() bool {
-	return len(r.Errors) == 0
+	return r.ErrorCount() == 0
+}
+
+// HasWarnings returns true if any entry has warning severity.
+func (r *ValidationResult) HasWarnings() bool {
+	return r.WarningCount() > 0
+}
+
+// WarningCount returns the number of warning-severity entries, including suppressed ones.
+func (r *ValidationResult) WarningCount() int {
+	return r.severityCount(SeverityWarning) + r.suppressedWarnings
+}
+
+// HasInfo returns true if any entry has info severity.
+func (r *ValidationResult) HasInfo() bool {
+	return r.InfoCount() > 0
+}
+
+// InfoCount returns the number of info-severity entries, including suppressed ones.
+func (r *ValidationResult) InfoCount() int {
+	return r.severityCount(SeverityInfo) + r.suppressedInfo
+}
+
+func (r *ValidationResult) severityCount(severity ValidationSeverity) int {
+	count := 0
+	for _, err := range r.Errors {
+		if err.Severity == severity {
+			count++
+		}
+	}
+	return count
 }
 
-// ErrorCount returns the number of errors.
+// ErrorCount returns the number of error-severity entries, including any suppressed past the cap.
+// Warnings and info notes are counted by WarningCount and InfoCount.
 func (r *ValidationResult) ErrorCount() int {
-	return len(r.Errors)
+	return r.severityCount(SeverityError) + r.suppressedErrors()
+}
+
+func (r *ValidationResult) suppressedErrors() int {
+	return r.suppressed - r.suppressedWarnings - r.suppressedInfo
+}
+
+// Suppressed returns the number of entries of any severity counted but not stored because of the cap.
+func (r *ValidationResult) Suppressed() int {
+	return r.suppressed
+}
+
+// ForEach calls fn for each stored error in order until fn returns false.
+func (r *ValidationResult) ForEach(fn func(ValidationError) bool) {
+	for _, err := range r.Errors {
+		if !fn(err) {
+			return
+		}
+	}
+}
+
+// All returns an iterator over the stored errors for use with range.
+func (r *ValidationResult) All() iter.Seq[ValidationError] {
+	return r.ForEach
+}
+
+// ContainsConstraint reports whether any stored error has the given constraint type.
+func (r *ValidationResult) ContainsConstraint(constraintType string) bool {
+	for _, err := range r.Errors {
+		if err.ConstraintType == constraintType {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsField reports whether any stored error is for the given table field.
+func (r *ValidationResult) ContainsField(tableName, fieldName string) bool {
+	for _, err := range r.Errors {
+		if err.TableName == tableName && err.FieldName == fieldName {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsRow reports whether any stored error is for the given table row.
+func (r *ValidationResult) ContainsRow(tableName, rowKey string) bool {
+	for _, err := range r.Errors {
+		if err.TableName == tableName && err.RowKey == rowKey {
+			return true
+		}
+	}
+	return false
+}
+
+// ByRow returns a new result holding the stored errors for rowKey in tableName.
+// An empty tableName matches the row key in any table.
+func (r *ValidationResult) ByRow(tableName, rowKey string) *ValidationResult {
+	out := NewValidationResult()
+	for _, err := range r.Errors {
+		if (tableName == "" || err.TableName == tableName) && err.RowKey == rowKey {
+			out.AddError(err)
+		}
+	}
+	return out
 }
 
-// Merge combines another validation result into this one.
+// Merge combines another validation result into this one, respecting this result's cap.
 func (r *ValidationResult) Merge(other *ValidationResult) {
 	if other != nil {
-		r.Errors = append(r.Errors, other.Errors...)
+		for _, err := range other.Errors {
+			r.AddError(err)
+		}
+		r.suppressed += other.suppressed
+		r.suppressedWarnings += other.suppressedWarnings
+		r.suppressedInfo += other.suppressedInfo
 	}
 }
 
 func (r *ValidationResult) String() string {
-	if r.IsValid() {
+	if len(r.Errors) == 0 && r.suppressed == 0 {
 		return "Validation passed"
 	}
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Validation failed with %d error(s):\n", len(r.Errors)))
+	sb.WriteString(r.summaryLine())
 	for _, err := range r.Errors {
 		sb.WriteString("  - ")
 		sb.WriteString(err.String())
 		sb.WriteString("\n")
 	}
+	if r.suppressed > 0 {
+		sb.WriteString(fmt.Sprintf("  ... and %d more\n", r.suppressed))
+	}
+	return sb.String()
+}
+
+// StringGrouped formats the result like String, but lists errors under a header per
+// table (sorted by table name, with counts), keeping their order within each table.
+func (r *ValidationResult) StringGrouped() string {
+	if len(r.Errors) == 0 && r.suppressed == 0 {
+		return "Validation passed"
+	}
+	groups := make(map[string][]ValidationError)
+	for _, err := range r.Errors {
+		groups[err.TableName] = append(groups[err.TableName], err)
+	}
+	var sb strings.Builder
+	sb.WriteString(r.summaryLine())
+	for _, table := range slices.Sorted(maps.Keys(groups)) {
+		sb.WriteString(fmt.Sprintf("  %s (%d):\n", table, len(groups[table])))
+		for _, err := range groups[table] {
+			sb.WriteString("    - ")
+			sb.WriteString(err.String())
+			sb.WriteString("\n")
+		}
+	}
+	if r.suppressed > 0 {
+		sb.WriteString(fmt.Sprintf("  ... and %d more\n", r.suppressed))
+	}
 	return sb.String()
 }
 
+func (r *ValidationResult) summaryLine() string {
+	switch {
+	case r.IsValid() && r.InfoCount() > 0:
+		return fmt.Sprintf("Validation passed with %d warning(s) and %d info note(s):\n", r.WarningCount(), r.InfoCount())
+	case r.IsValid():
+		return fmt.Sprintf("Validation passed with %d warning(s):\n", r.WarningCount())
+	default:
+		return fmt.Sprintf("Validation failed with %d error(s):\n", r.ErrorCount())
+	}
+}
+
+type validationResultJSON struct {
+	Errors     []ValidationError `json:"errors"`
+	Suppressed int               `json:"suppressed,omitempty"`
+	// SuppressedWarnings is the portion of Suppressed that were warnings.
+	SuppressedWarnings int `json:"suppressed_warnings,omitempty"`
+	// SuppressedInfo is the portion of Suppressed that were info notes.
+	SuppressedInfo int `json:"suppressed_info,omitempty"`
+}
+
+// MarshalJSON encodes the result as {"errors": [...], "suppressed": n}.
+func (r ValidationResult) MarshalJSON() ([]byte, error) {
+	errs := r.Errors
+	if errs == nil {
+		errs = []ValidationError{}
+	}
+	return json.Marshal(validationResultJSON{Errors: errs, Suppressed: r.suppressed, SuppressedWarnings: r.suppressedWarnings, SuppressedInfo: r.suppressedInfo})
+}
+
+// UnmarshalJSON decodes a result produced by MarshalJSON.
+func (r *ValidationResult) UnmarshalJSON(data []byte) error {
+	var decoded validationResultJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Errors == nil {
+		decoded.Errors = make([]ValidationError, 0)
+	}
+	r.Errors = decoded.Errors
+	r.suppressed = decoded.Suppressed
+	r.suppressedWarnings = decoded.SuppressedWarnings
+	r.suppressedInfo = decoded.SuppressedInfo
+	return nil
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitXML writes the result as a JUnit XML <testsuite> with one failing
+// <testcase> per table/field combination that has error-severity entries (classname is the table).
+// Warnings and info notes do not fail the suite; they are listed in <system-out>.
+// Errors dropped by the cap are reported as one failing "suppressed" test case.
+// A result without errors is written as a single passing "validation" test case.
+func (r *ValidationResult) WriteJUnitXML(w io.Writer, suiteName string) error {
+	suite := junitTestSuite{Name: suiteName}
+	index := make(map[[2]string]int)
+	var failures [][]string
+	var notes []string
+	for _, err := range r.Errors {
+		if err.Severity != SeverityError {
+			notes = append(notes, err.String())
+			continue
+		}
+		key := [2]string{err.TableName, err.FieldName}
+		i, ok := index[key]
+		if !ok {
+			i = len(suite.TestCases)
+			index[key] = i
+			suite.TestCases = append(suite.TestCases, junitTestCase{ClassName: err.TableName, Name: err.FieldName})
+			failures = append(failures, nil)
+		}
+		failures[i] = append(failures[i], err.String())
+	}
+	for i := range suite.TestCases {
+		suite.TestCases[i].Failure = &junitFailure{
+			Message: fmt.Sprintf("%d validation error(s)", len(failures[i])),
+			Type:    "ValidationError",
+			Text:    strings.Join(failures[i], "\n"),
+		}
+	}
+	failureCount := len(failures)
+	// Errors dropped by the cap still make the result invalid, so they get their own failing case.
+	if n := r.suppressedErrors(); n > 0 {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			ClassName: suiteName,
+			Name:      "suppressed",
+			Failure: &junitFailure{
+				Message: fmt.Sprintf("%d error(s) suppressed", n),
+				Type:    "ValidationError",
+				Text:    fmt.Sprintf("%d more validation error(s) were suppressed by the error cap", n),
+			},
+		})
+		failureCount++
+	}
+	if len(suite.TestCases) == 0 {
+		suite.TestCases = append(suite.TestCases, junitTestCase{ClassName: suiteName, Name: "validation"})
+	}
+	if r.suppressed > 0 {
+		notes = append(notes, fmt.Sprintf("%d more validation entries were suppressed", r.suppressed))
+	}
+	suite.SystemOut = strings.Join(notes, "\n")
+	suite.Tests = len(suite.TestCases)
+	suite.Failures = failureCount
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ErrValidationFailed is matched by errors.Is for every ValidationException.
+var ErrValidationFailed = errors.New("validation failed")
+
 // ValidationException wraps a validation result as an error.
+// Use errors.As(err, &ve) with ve of type *ValidationException to recover the result.
 type ValidationException struct {
 	Result *ValidationResult
 }
@@ -108,11 +491,104 @@ func (e *ValidationException) Error() string {
 	return e.Result.String()
 }
 
+// Is reports whether target is ErrValidationFailed or a ValidationException
+// wrapping the same Result.
+func (e *ValidationException) Is(target error) bool {
+	if target == ErrValidationFailed {
+		return true
+	}
+	other, ok := target.(*ValidationException)
+	return ok && other != nil && other.Result == e.Result
+}
+
 // NewValidationException creates a new validation exception.
 func NewValidationException(result *ValidationResult) *ValidationException {
 	return &ValidationException{Result: result}
 }
 
+// Validator aggregates validation results from multiple tables.
+type Validator struct {
+	order   []string
+	results map[string]*ValidationResult
+}
+
+// NewValidator creates an empty multi-table validator.
+func NewValidator() *Validator {
+	return &Validator{results: make(map[string]*ValidationResult)}
+}
+
+// AddResult records r under table, merging with any result already added for that table.
+func (v *Validator) AddResult(table string, r *ValidationResult) {
+	existing, ok := v.results[table]
+	if !ok {
+		existing = NewValidationResult()
+		v.results[table] = existing
+		v.order = append(v.order, table)
+	}
+	existing.Merge(r)
+}
+
+// Result returns all errors combined in the order tables were first added.
+func (v *Validator) Result() *ValidationResult {
+	combined := NewValidationResult()
+	for _, table := range v.order {
+		combined.Merge(v.results[table])
+	}
+	return combined
+}
+
+// Summary returns the error count for every table added, including tables without errors.
+func (v *Validator) Summary() map[string]int {
+	summary := make(map[string]int, len(v.results))
+	for table, r := range v.results {
+		summary[table] = r.ErrorCount()
+	}
+	return summary
+}
+
+// Err returns a ValidationException for the combined result, or nil if every table is valid.
+func (v *Validator) Err() error {
+	combined := v.Result()
+	if combined.IsValid() {
+		return nil
+	}
+	return NewValidationException(combined)
+}
+
+// ValidationPipeline runs a sequence of validators and merges their results.
+type ValidationPipeline struct {
+	// FailFast stops the pipeline after the first validator whose result is not valid.
+	FailFast   bool
+	validators []func() *ValidationResult
+}
+
+// NewValidationPipeline creates a pipeline running validators in order.
+func NewValidationPipeline(validators ...func() *ValidationResult) *ValidationPipeline {
+	return &ValidationPipeline{validators: validators}
+}
+
+// Add appends a validator to the pipeline.
+func (p *ValidationPipeline) Add(validator func() *ValidationResult) {
+	p.validators = append(p.validators, validator)
+}
+
+// Run executes the validators in order and returns their merged result.
+// A validator may return nil to report nothing.
+func (p *ValidationPipeline) Run() *ValidationResult {
+	combined := NewValidationResult()
+	for _, validator := range p.validators {
+		r := validator()
+		if r == nil {
+			continue
+		}
+		combined.Merge(r)
+		if p.FailFast && !r.IsValid() {
+			break
+		}
+	}
+	return combined
+}
+
 // ============ Validation Helpers ============
 
 // ValidateMaxLength checks if a string's length is within the maximum.
@@ -128,6 +604,16 @@ func ValidateMaxLengthPtr(value *string, maxLen int) bool {
 	return len(*value) <= maxLen
 }
 
+// ValidateMaxItems checks if a repeated field has at most max elements.
+func ValidateMaxItems[T any](slice []T, max int) bool {
+	return len(slice) <= max
+}
+
+// ValidateMinItems checks if a repeated field has at least min elements.
+func ValidateMinItems[T any](slice []T, min int) bool {
+	return len(slice) >= min
+}
+
 // ValidateRangeInt checks if an integer is within the specified range.
 func ValidateRangeInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](value T, min, max T) bool {
 	return value >= min && value <= max
@@ -143,6 +629,68 @@ func ValidateRangeFloat[T ~float32 | ~float64](value T, min, max T) bool {
 	return value >= min && value <= max
 }
 
+// ValidateRangeIntPtr checks if an optional signed integer is within the specified range.
+func ValidateRangeIntPtr[T ~int | ~int8 | ~int16 | ~int32 | ~int64](value *T, min, max T) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateRangeInt(*value, min, max)
+}
+
+// ValidateRangeUintPtr checks if an optional unsigned integer is within the specified range.
+func ValidateRangeUintPtr[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](value *T, min, max T) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateRangeUint(*value, min, max)
+}
+
+// ValidateRangeFloatPtr checks if an optional float is within the specified range.
+func ValidateRangeFloatPtr[T ~float32 | ~float64](value *T, min, max T) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateRangeFloat(*value, min, max)
+}
+
+// ValidatePercent checks if a percentage is within [0, 100]. NaN never validates.
+func ValidatePercent(value float64) bool {
+	return ValidateRangeFloat(value, 0, 100)
+}
+
+// ValidateRatio checks if a ratio is within [0, 1]. NaN never validates.
+func ValidateRatio(value float64) bool {
+	return ValidateRangeFloat(value, 0, 1)
+}
+
+// ValidateMultipleOf checks if an integer is an exact multiple of step (e.g. prices in
+// increments of 5). A non-positive step never validates.
+func ValidateMultipleOf[T ~int | ~int8 | ~int16 | ~int32 | ~int64](value, step T) bool {
+	return step > 0 && value%step == 0
+}
+
+// ValidateMultipleOfPtr checks if an optional integer is an exact multiple of step.
+func ValidateMultipleOfPtr[T ~int | ~int8 | ~int16 | ~int32 | ~int64](value *T, step T) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateMultipleOf(*value, step)
+}
+
+// ValidateRangeString checks if a string is within the specified lexicographic range.
+// Comparison is a locale-independent byte comparison, so it is case-sensitive.
+func ValidateRangeString(value, min, max string) bool {
+	return value >= min && value <= max
+}
+
+// ValidateRangeStringPtr checks if an optional string is within the specified lexicographic range.
+func ValidateRangeStringPtr(value *string, min, max string) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateRangeString(*value, min, max)
+}
+
 // ValidateRegex checks if a string matches the specified pattern.
 func ValidateRegex(value, pattern string) bool {
 	re, err := regexp.Compile(pattern)
@@ -160,74 +708,424 @@ func ValidateRegexPtr(value *string, pattern string) bool {
 	return ValidateRegex(*value, pattern)
 }
 
-// ValidateRequired checks if a pointer value is not nil.
-func ValidateRequired[T any](value *T) bool {
-	return value != nil
+// ValidateRegexCompiled checks if a string matches a pre-compiled pattern, avoiding the
+// per-call compile of ValidateRegex; keep re in a package-level variable.
+func ValidateRegexCompiled(value string, re *regexp.Regexp) bool {
+	return re.MatchString(value)
 }
 
-// ============ Error Creators ============
-
-// MaxLengthError creates a validation error for max length constraint violation.
-func MaxLengthError(tableName, fieldName, rowKey string, maxLen, actualLen int) ValidationError {
-	return ValidationError{
-		TableName:      tableName,
-		FieldName:      fieldName,
-		RowKey:         rowKey,
-		Message:        fmt.Sprintf("length %d exceeds maximum %d", actualLen, maxLen),
-		Severity:       SeverityError,
-		ConstraintType: "MaxLength",
+// ValidateRegexCompiledPtr checks if an optional string matches a pre-compiled pattern.
+func ValidateRegexCompiledPtr(value *string, re *regexp.Regexp) bool {
+	if value == nil {
+		return true
 	}
+	return re.MatchString(*value)
 }
 
-// RangeError creates a validation error for range constraint violation.
-func RangeError[T any](tableName, fieldName, rowKey string, min, max, actual T) ValidationError {
-	return ValidationError{
-		TableName:      tableName,
-		FieldName:      fieldName,
-		RowKey:         rowKey,
-		Message:        fmt.Sprintf("value %v is outside range [%v, %v]", actual, min, max),
-		Severity:       SeverityError,
-		ConstraintType: "Range",
+// FindDuplicates returns each key that appears more than once, mapped to the
+// indices where it appears in ascending order.
+func FindDuplicates[K comparable](keys []K) map[K][]int {
+	positions := make(map[K][]int, len(keys))
+	for i, key := range keys {
+		positions[key] = append(positions[key], i)
+	}
+	for key, indices := range positions {
+		if len(indices) < 2 {
+			delete(positions, key)
+		}
 	}
+	return positions
 }
 
-// RegexError creates a validation error for regex constraint violation.
-func RegexError(tableName, fieldName, rowKey, pattern, actual string) ValidationError {
-	return ValidationError{
-		TableName:      tableName,
-		FieldName:      fieldName,
-		RowKey:         rowKey,
-		Message:        fmt.Sprintf("value '%s' does not match pattern '%s'", actual, pattern),
-		Severity:       SeverityError,
-		ConstraintType: "Regex",
+// ValidateUniqueSlice reports a UniqueError, in item order, for every item whose key repeats
+// an earlier item's. RowKey comes from rowKeyFn; the message gives the item's 0-based index
+// and the index of the key's first occurrence.
+func ValidateUniqueSlice[T any, K comparable](items []T, keyFn func(T) K, rowKeyFn func(T) string, table, field string) *ValidationResult {
+	result := NewValidationResult()
+	keys := make([]K, len(items))
+	for i, item := range items {
+		keys[i] = keyFn(item)
+	}
+	duplicates := FindDuplicates(keys)
+	for i, item := range items {
+		indices, ok := duplicates[keys[i]]
+		if !ok || indices[0] == i {
+			continue
+		}
+		err := UniqueError(table, field, rowKeyFn(item), keys[i])
+		err.Message += fmt.Sprintf(" (index %d, first at index %d)", i, indices[0])
+		result.AddError(err)
 	}
+	return result
 }
 
-// RequiredError creates a validation error for required field constraint violation.
-func RequiredError(tableName, fieldName, rowKey string) ValidationError {
-	return ValidationError{
-		TableName:      tableName,
-		FieldName:      fieldName,
-		RowKey:         rowKey,
-		Message:        "required field is nil",
-		Severity:       SeverityError,
-		ConstraintType: "Required",
-	}
+// ValidateUniqueElements checks that a single value's list (e.g. a comma-separated ID cell)
+// has no repeated elements.
+func ValidateUniqueElements[T comparable](values []T) bool {
+	_, found := FirstDuplicate(values)
+	return !found
 }
 
-// ForeignKeyError creates a validation error for foreign key constraint violation.
-func ForeignKeyError(tableName, fieldName, rowKey, refTable string, refKey interface{}) ValidationError {
-	return ValidationError{
-		TableName:      tableName,
-		FieldName:      fieldName,
-		RowKey:         rowKey,
-		Message:        fmt.Sprintf("foreign key %v not found in %s", refKey, refTable),
-		Severity:       SeverityError,
-		ConstraintType: "ForeignKey",
+// FirstDuplicate returns the first element that repeats an earlier one, for UniqueSliceError.
+func FirstDuplicate[T comparable](values []T) (T, bool) {
+	seen := make(map[T]struct{}, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			return value, true
+		}
+		seen[value] = struct{}{}
 	}
+	var zero T
+	return zero, false
 }
 
-// UniqueError creates a validation error for unique constraint violation.
+// ValidateURL checks if a string is an absolute http or https URL with a host, such as
+// http://example.com/path. Relative references and other schemes are rejected; use
+// ValidateURLScheme for those.
+func ValidateURL(value string) bool {
+	return ValidateURLScheme(value, "http") || ValidateURLScheme(value, "https")
+}
+
+// ValidateURLPtr checks if an optional string is an absolute http or https URL.
+func ValidateURLPtr(value *string) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateURL(*value)
+}
+
+// validationEmailRegexp is a deliberately simple address check: a dot-atom local part,
+// "@", and a domain of dot-separated labels ending in a TLD of at least two letters.
+// It does not accept quoted local parts, IP-literal domains, or comments from RFC 5322.
+var validationEmailRegexp = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+/=?^_{|}~-]+(\.[A-Za-z0-9!#$%&'*+/=?^_{|}~-]+)*@([A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?\.)+[A-Za-z]{2,}$`)
+
+// ValidateEmail checks if a string looks like an email address (RFC 5322-lite, see validationEmailRegexp).
+func ValidateEmail(value string) bool {
+	return validationEmailRegexp.MatchString(value)
+}
+
+// ValidateEmailPtr checks if an optional string looks like an email address.
+func ValidateEmailPtr(value *string) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateEmail(*value)
+}
+
+// ValidateURLScheme checks if a string is an absolute URL using requiredScheme
+// (compared case-insensitively, without "://"), e.g. "https".
+func ValidateURLScheme(value, requiredScheme string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.Host != "" && strings.EqualFold(parsed.Scheme, requiredScheme)
+}
+
+// ValidateNotEmpty checks if a string has at least one byte.
+func ValidateNotEmpty(value string) bool {
+	return len(value) > 0
+}
+
+// ValidateNotEmptyPtr checks if an optional string has at least one byte (nil passes).
+func ValidateNotEmptyPtr(value *string) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateNotEmpty(*value)
+}
+
+// ValidateNotBlank checks if a string contains a non-whitespace character.
+func ValidateNotBlank(value string) bool {
+	return strings.TrimSpace(value) != ""
+}
+
+// ValidateNotBlankPtr checks if an optional string contains a non-whitespace character (nil passes).
+func ValidateNotBlankPtr(value *string) bool {
+	if value == nil {
+		return true
+	}
+	return ValidateNotBlank(*value)
+}
+
+// ValidateConsistency checks that dependentField is set whenever conditionField is set.
+// The two fields may have different types.
+func ValidateConsistency[C any, D any](conditionField *C, dependentField *D) bool {
+	return conditionField == nil || dependentField != nil
+}
+
+// ValidateConditional applies check only when cond holds, so rules like
+// "discount is required when the item is on sale" read as a single expression.
+func ValidateConditional(cond bool, check func() bool) bool {
+	return !cond || check()
+}
+
+// ValidateRequired checks if a pointer value is not nil.
+func ValidateRequired[T any](value *T) bool {
+	return value != nil
+}
+
+// ============ Error Creators ============
+
+// CodedError creates a custom validation error identified by a machine-readable code.
+func CodedError(tableName, fieldName, rowKey, code, message string, severity ValidationSeverity) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        message,
+		Severity:       severity,
+		ConstraintType: "Custom",
+		Code:           code,
+	}
+}
+
+// InfoError creates an informational validation note, e.g. for a deprecated field value.
+func InfoError(tableName, fieldName, rowKey, message string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        message,
+		Severity:       SeverityInfo,
+		ConstraintType: "Info",
+		Code:           "INFO",
+	}
+}
+
+// MaxLengthError creates a validation error for max length constraint violation.
+func MaxLengthError(tableName, fieldName, rowKey string, maxLen, actualLen int) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("length %d exceeds maximum %d", actualLen, maxLen),
+		Severity:       SeverityError,
+		ConstraintType: "MaxLength",
+		Code:           "MAX_LENGTH",
+	}
+}
+
+// MaxItemsError creates a validation error for a repeated field with too many elements.
+func MaxItemsError(tableName, fieldName, rowKey string, max, actual int) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("%d items exceeds maximum %d", actual, max),
+		Severity:       SeverityError,
+		ConstraintType: "MaxItems",
+		Code:           "MAX_ITEMS",
+	}
+}
+
+// MinItemsError creates a validation error for a repeated field with too few elements.
+func MinItemsError(tableName, fieldName, rowKey string, min, actual int) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("%d items is below minimum %d", actual, min),
+		Severity:       SeverityError,
+		ConstraintType: "MinItems",
+		Code:           "MIN_ITEMS",
+	}
+}
+
+// RangeError creates a validation error for range constraint violation.
+func RangeError[T any](tableName, fieldName, rowKey string, min, max, actual T) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value %v is outside range [%v, %v]", actual, min, max),
+		Severity:       SeverityError,
+		ConstraintType: "Range",
+		Code:           "RANGE",
+	}
+}
+
+// PercentError creates a validation error for a percentage outside [0, 100].
+func PercentError(tableName, fieldName, rowKey string, actual float64) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value %v is not a percentage in [0, 100]", actual),
+		Severity:       SeverityError,
+		ConstraintType: "Percent",
+		Code:           "PERCENT",
+	}
+}
+
+// RatioError creates a validation error for a ratio outside [0, 1].
+func RatioError(tableName, fieldName, rowKey string, actual float64) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value %v is not a ratio in [0, 1]", actual),
+		Severity:       SeverityError,
+		ConstraintType: "Ratio",
+		Code:           "RATIO",
+	}
+}
+
+// MultipleOfError creates a validation error for a value that is not a multiple of step.
+func MultipleOfError[T any](tableName, fieldName, rowKey string, step, actual T) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value %v is not a multiple of %v", actual, step),
+		Severity:       SeverityError,
+		ConstraintType: "MultipleOf",
+		Code:           "MULTIPLE_OF",
+	}
+}
+
+// RangeStringError creates a validation error for lexicographic string range constraint violation.
+func RangeStringError(tableName, fieldName, rowKey, min, max, actual string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value '%s' is outside range ['%s', '%s']", actual, min, max),
+		Severity:       SeverityError,
+		ConstraintType: "RangeString",
+		Code:           "RANGE_STRING",
+	}
+}
+
+// RegexError creates a validation error for regex constraint violation.
+func RegexError(tableName, fieldName, rowKey, pattern, actual string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value '%s' does not match pattern '%s'", actual, pattern),
+		Severity:       SeverityError,
+		ConstraintType: "Regex",
+		Code:           "REGEX",
+	}
+}
+
+// ConsistencyError creates a validation error for a dependent field left unset while its condition field is set.
+func ConsistencyError(tableName, conditionField, dependentField, rowKey string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      dependentField,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("%s is required when %s is set", dependentField, conditionField),
+		Severity:       SeverityError,
+		ConstraintType: "Consistency",
+		Code:           "CONSISTENCY",
+	}
+}
+
+// URLError creates a validation error for a value that is not an absolute URL.
+func URLError(tableName, fieldName, rowKey, actual string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value '%s' is not an absolute URL", actual),
+		Severity:       SeverityError,
+		ConstraintType: "URL",
+		Code:           "URL",
+	}
+}
+
+// URLSchemeError creates a validation error for a URL that does not use the required scheme.
+func URLSchemeError(tableName, fieldName, rowKey, required, actual string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value '%s' is not a %s URL", actual, required),
+		Severity:       SeverityError,
+		ConstraintType: "URLScheme",
+		Code:           "URL_SCHEME",
+	}
+}
+
+// EmailError creates a validation error for a value that is not an email address.
+func EmailError(tableName, fieldName, rowKey, actual string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("value '%s' is not a valid email address", actual),
+		Severity:       SeverityError,
+		ConstraintType: "Email",
+		Code:           "EMAIL",
+	}
+}
+
+// RequiredError creates a validation error for required field constraint violation.
+func RequiredError(tableName, fieldName, rowKey string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        "required field is nil",
+		Severity:       SeverityError,
+		ConstraintType: "Required",
+		Code:           "REQUIRED",
+	}
+}
+
+// NotEmptyError creates a validation error for an empty string.
+func NotEmptyError(tableName, fieldName, rowKey string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        "string must not be empty",
+		Severity:       SeverityError,
+		ConstraintType: "NotEmpty",
+		Code:           "NOT_EMPTY",
+	}
+}
+
+// NotBlankError creates a validation error for a string that is empty or only whitespace.
+func NotBlankError(tableName, fieldName, rowKey string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        "string must not be blank",
+		Severity:       SeverityError,
+		ConstraintType: "NotBlank",
+		Code:           "NOT_BLANK",
+	}
+}
+
+// ConditionalError creates a validation error for a rule that applies because
+// governingField has governingValue.
+func ConditionalError(tableName, fieldName, rowKey, governingField string, governingValue interface{}, message string) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("%s when %s is %v", message, governingField, governingValue),
+		Severity:       SeverityError,
+		ConstraintType: "Conditional",
+		Code:           "CONDITIONAL",
+	}
+}
+
+// ForeignKeyError creates a validation error for foreign key constraint violation.
+func ForeignKeyError(tableName, fieldName, rowKey, refTable string, refKey interface{}) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("foreign key %v not found in %s", refKey, refTable),
+		Severity:       SeverityError,
+		ConstraintType: "ForeignKey",
+		Code:           "FOREIGN_KEY",
+	}
+}
+
+// UniqueError creates a validation error for unique constraint violation.
 func UniqueError(tableName, fieldName, rowKey string, value interface{}) ValidationError {
 	return ValidationError{
 		TableName:      tableName,
@@ -236,6 +1134,20 @@ func UniqueError(tableName, fieldName, rowKey string, value interface{}) Validat
 		Message:        fmt.Sprintf("duplicate value '%v' violates unique constraint", value),
 		Severity:       SeverityError,
 		ConstraintType: "Unique",
+		Code:           "UNIQUE",
+	}
+}
+
+// UniqueSliceError creates a validation error for a repeated element within one list value.
+func UniqueSliceError(tableName, fieldName, rowKey string, duplicate interface{}) ValidationError {
+	return ValidationError{
+		TableName:      tableName,
+		FieldName:      fieldName,
+		RowKey:         rowKey,
+		Message:        fmt.Sprintf("list contains duplicate element '%v'", duplicate),
+		Severity:       SeverityError,
+		ConstraintType: "UniqueSlice",
+		Code:           "UNIQUE_SLICE",
 	}
 }
 
@@ -391,6 +1303,38 @@ func unsupportedPackedField(typeName, fieldName, fieldType string) error {
 type CsvRow struct {
 	headers map[string]int
 	values  []string
+	record  int
+	line    int
+	// bools points at the owning reader's token set; nil uses the defaults.
+	bools *csvBoolTokens
+}
+
+// csvBoolTokens holds the lower-cased cell values GetBool and TryGetBool recognise.
+type csvBoolTokens struct {
+	truthy []string
+	falsy  []string
+}
+
+var defaultCsvBoolTokens = csvBoolTokens{
+	truthy: []string{"true", "1", "yes"},
+	falsy:  []string{"false", "0", "no"},
+}
+
+func (r *CsvRow) boolTokens() *csvBoolTokens {
+	if r.bools != nil && (r.bools.truthy != nil || r.bools.falsy != nil) {
+		return r.bools
+	}
+	return &defaultCsvBoolTokens
+}
+
+// RecordNumber returns the 1-based data record number of this row, not counting the header.
+func (r *CsvRow) RecordNumber() int {
+	return r.record
+}
+
+// LineNumber returns the 1-based physical line where this row starts in the CSV file.
+func (r *CsvRow) LineNumber() int {
+	return r.line
 }
 
 // Get returns the raw column value and whether the column exists in this row.
@@ -402,6 +1346,11 @@ func (r *CsvRow) Get(column string) (string, bool) {
 	return r.values[idx], true
 }
 
+// ColumnCount returns the number of values in this row, which may be fewer than the headers for truncated rows.
+func (r *CsvRow) ColumnCount() int {
+	return len(r.values)
+}
+
 // GetString gets a string value by column name.
 func (r *CsvRow) GetString(column string) string {
 	if val, ok := r.Get(column); ok {
@@ -476,30 +1425,355 @@ func (r *CsvRow) GetFloat64(column string) float64 {
 	return 0
 }
 
-// GetBool gets a bool value by column name.
+// GetBool gets a bool value by column name. Cells are trimmed and matched case-insensitively,
+// as in TryGetBool. Values outside the truthy set, including empty cells, are false; the set
+// defaults to true/1/yes and is configurable via CsvReader.SetBoolTokens.
 func (r *CsvRow) GetBool(column string) bool {
 	if idx, ok := r.headers[column]; ok && idx < len(r.values) {
-		val := strings.ToLower(r.values[idx])
-		return val == "true" || val == "1" || val == "yes"
+		return slices.Contains(r.boolTokens().truthy, strings.ToLower(strings.TrimSpace(r.values[idx])))
 	}
 	return false
 }
 
-// CsvReader reads CSV files with header support.
-type CsvReader struct {
-	headers map[string]int
-	reader  *csv.Reader
-	file    *os.File
+// TryGetBool gets a bool value by column name, returning an error for a missing column
+// or a value that is in neither the truthy nor the falsy set (including an empty cell).
+func (r *CsvRow) TryGetBool(column string) (bool, error) {
+	val, ok := r.Get(column)
+	if !ok {
+		return false, fmt.Errorf("missing CSV column %q", column)
+	}
+	tokens := r.boolTokens()
+	lower := strings.ToLower(strings.TrimSpace(val))
+	switch {
+	case slices.Contains(tokens.truthy, lower):
+		return true, nil
+	case slices.Contains(tokens.falsy, lower):
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid CSV column %q bool value %q", column, val)
+	}
 }
 
-// NewCsvReader creates a new CSV reader from a file path.
-func NewCsvReader(path string) (*CsvReader, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
+// nonEmpty returns the trimmed cell value when the column exists and is not blank.
+func (r *CsvRow) nonEmpty(column string) (string, bool) {
+	val, ok := r.Get(column)
+	if !ok {
+		return "", false
 	}
+	val = strings.TrimSpace(val)
+	return val, val != ""
+}
 
-	reader := csv.NewReader(file)
+// GetStringOr gets a string value by column name, or def if the column is missing or empty.
+func (r *CsvRow) GetStringOr(column string, def string) string {
+	if val, ok := r.Get(column); ok && val != "" {
+		return val
+	}
+	return def
+}
+
+// GetInt32Or gets an int32 value by column name, or def if the column is missing, empty, or invalid.
+func (r *CsvRow) GetInt32Or(column string, def int32) int32 {
+	if val, ok := r.nonEmpty(column); ok {
+		if parsed, err := strconv.ParseInt(val, 10, 32); err == nil {
+			return int32(parsed)
+		}
+	}
+	return def
+}
+
+// GetInt64Or gets an int64 value by column name, or def if the column is missing, empty, or invalid.
+func (r *CsvRow) GetInt64Or(column string, def int64) int64 {
+	if val, ok := r.nonEmpty(column); ok {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return def
+}
+
+// GetUint32Or gets a uint32 value by column name, or def if the column is missing, empty, or invalid.
+func (r *CsvRow) GetUint32Or(column string, def uint32) uint32 {
+	if val, ok := r.nonEmpty(column); ok {
+		if parsed, err := strconv.ParseUint(val, 10, 32); err == nil {
+			return uint32(parsed)
+		}
+	}
+	return def
+}
+
+// GetUint64Or gets a uint64 value by column name, or def if the column is missing, empty, or invalid.
+func (r *CsvRow) GetUint64Or(column string, def uint64) uint64 {
+	if val, ok := r.nonEmpty(column); ok {
+		if parsed, err := strconv.ParseUint(val, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return def
+}
+
+// GetFloat32Or gets a float32 value by column name, or def if the column is missing, empty, or invalid.
+func (r *CsvRow) GetFloat32Or(column string, def float32) float32 {
+	if val, ok := r.nonEmpty(column); ok {
+		if parsed, err := strconv.ParseFloat(val, 32); err == nil {
+			return float32(parsed)
+		}
+	}
+	return def
+}
+
+// GetFloat64Or gets a float64 value by column name, or def if the column is missing, empty, or invalid.
+func (r *CsvRow) GetFloat64Or(column string, def float64) float64 {
+	if val, ok := r.nonEmpty(column); ok {
+		if parsed, err := strconv.ParseFloat(val, 64); err == nil {
+			return parsed
+		}
+	}
+	return def
+}
+
+// GetBoolOr gets a bool value by column name, or def if the column is missing or empty.
+func (r *CsvRow) GetBoolOr(column string, def bool) bool {
+	if _, ok := r.nonEmpty(column); ok {
+		return r.GetBool(column)
+	}
+	return def
+}
+
+// GetUUID parses an RFC 4122 UUID by column name, in canonical hyphenated form
+// or as 32 hex digits. Hex digits may be upper or lower case.
+func (r *CsvRow) GetUUID(column string) ([16]byte, error) {
+	val, ok := r.Get(column)
+	if !ok {
+		return [16]byte{}, fmt.Errorf("missing CSV column %q", column)
+	}
+	uuid, err := parseUUID(strings.TrimSpace(val))
+	if err != nil {
+		return [16]byte{}, fmt.Errorf("invalid CSV column %q UUID value %q: %w", column, val, err)
+	}
+	return uuid, nil
+}
+
+// GetUUIDPtr parses an optional UUID by column name, returning nil for missing or empty cells.
+func (r *CsvRow) GetUUIDPtr(column string) (*[16]byte, error) {
+	if _, ok := r.nonEmpty(column); !ok {
+		return nil, nil
+	}
+	uuid, err := r.GetUUID(column)
+	if err != nil {
+		return nil, err
+	}
+	return &uuid, nil
+}
+
+// GetBase64URL decodes a padded URL-safe base64 cell ("-" and "_" instead of "+" and "/").
+// Standard base64 input containing "+" or "/" is rejected.
+func (r *CsvRow) GetBase64URL(column string) ([]byte, error) {
+	return r.getBase64(column, base64.URLEncoding, "base64url")
+}
+
+// GetBase64URLNoPad decodes an unpadded URL-safe base64 cell.
+func (r *CsvRow) GetBase64URLNoPad(column string) ([]byte, error) {
+	return r.getBase64(column, base64.RawURLEncoding, "unpadded base64url")
+}
+
+func (r *CsvRow) getBase64(column string, encoding *base64.Encoding, kind string) ([]byte, error) {
+	val, ok := r.Get(column)
+	if !ok {
+		return nil, fmt.Errorf("missing CSV column %q", column)
+	}
+	decoded, err := encoding.DecodeString(strings.TrimSpace(val))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV column %q %s value %q: %w", column, kind, val, err)
+	}
+	return decoded, nil
+}
+
+func parseUUID(value string) ([16]byte, error) {
+	var uuid [16]byte
+	switch len(value) {
+	case 36:
+		if value[8] != '-' || value[13] != '-' || value[18] != '-' || value[23] != '-' {
+			return uuid, fmt.Errorf("expected hyphens at positions 8, 13, 18 and 23")
+		}
+		value = value[0:8] + value[9:13] + value[14:18] + value[19:23] + value[24:]
+	case 32:
+	default:
+		return uuid, fmt.Errorf("expected 32 hex digits with optional hyphens, got %d characters", len(value))
+	}
+	if _, err := hex.Decode(uuid[:], []byte(value)); err != nil {
+		return [16]byte{}, err
+	}
+	return uuid, nil
+}
+
+// GetEnum maps a column's cell to an enum value. The cell is looked up in mapping
+// by name; a numeric cell is also accepted when it equals one of mapping's values.
+func GetEnum[E ~int32](row *CsvRow, column string, mapping map[string]E) (E, error) {
+	val, ok := row.Get(column)
+	if !ok {
+		return 0, fmt.Errorf("missing CSV column %q", column)
+	}
+	trimmed := strings.TrimSpace(val)
+	if enum, ok := mapping[trimmed]; ok {
+		return enum, nil
+	}
+	if numeric, err := strconv.ParseInt(trimmed, 10, 32); err == nil {
+		for _, enum := range mapping {
+			if int64(enum) == numeric {
+				return enum, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid CSV column %q enum value %q", column, val)
+}
+
+// GetEnumDefault maps a column's cell to an enum value like GetEnum, returning def
+// when the column is missing, empty, or not a known value.
+func GetEnumDefault[E ~int32](row *CsvRow, column string, mapping map[string]E, def E) E {
+	enum, err := GetEnum(row, column, mapping)
+	if err != nil {
+		return def
+	}
+	return enum
+}
+
+// TryGetMapped maps a column's trimmed cell to a value of any type through mapping,
+// failing with the column and raw value when the token is unknown. For int32 enums
+// that should also accept numeric cells, use GetEnum.
+func TryGetMapped[T any](row *CsvRow, column string, mapping map[string]T) (T, error) {
+	var zero T
+	val, ok := row.Get(column)
+	if !ok {
+		return zero, fmt.Errorf("missing CSV column %q", column)
+	}
+	if mapped, ok := mapping[strings.TrimSpace(val)]; ok {
+		return mapped, nil
+	}
+	return zero, fmt.Errorf("invalid CSV column %q value %q: not one of the mapped names", column, val)
+}
+
+// GetMapped maps a column's cell like TryGetMapped, returning def when the column is
+// missing or the token is unknown.
+func GetMapped[T any](row *CsvRow, column string, mapping map[string]T, def T) T {
+	mapped, err := TryGetMapped(row, column, mapping)
+	if err != nil {
+		return def
+	}
+	return mapped
+}
+
+// Common layouts for CsvRow.GetDate and CsvRow.GetDatePtr.
+const (
+	CsvDateLayout     = "2006-01-02"
+	CsvDateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// GetDate parses a time value by column name using layout.
+func (r *CsvRow) GetDate(column, layout string) (time.Time, error) {
+	val, ok := r.Get(column)
+	if !ok {
+		return time.Time{}, fmt.Errorf("missing CSV column %q", column)
+	}
+	parsed, err := time.Parse(layout, strings.TrimSpace(val))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid CSV column %q date value %q: %w", column, val, err)
+	}
+	return parsed, nil
+}
+
+// GetDatePtr parses an optional time value by column name, returning nil for missing or empty cells.
+func (r *CsvRow) GetDatePtr(column, layout string) (*time.Time, error) {
+	val, ok := r.Get(column)
+	if !ok || strings.TrimSpace(val) == "" {
+		return nil, nil
+	}
+	parsed, err := r.GetDate(column, layout)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// CsvReader reads CSV files with header support.
+type CsvReader struct {
+	headers map[string]int
+	reader  *csv.Reader
+	file    *os.File
+	rowNum  int
+	line    int
+	onError func(rowNum int, err error)
+	peeked  bool
+	peekRow *CsvRow
+	peekErr error
+	bools   csvBoolTokens
+	// skipBlanks drops all-empty records in readRow.
+	skipBlanks bool
+	// trimValues applies strings.TrimSpace to every cell in readRow.
+	trimValues bool
+	// ctx is checked before every row, since cancellation alone does not stop rows
+	// already buffered by bufio or encoding/csv.
+	ctx context.Context
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// CsvReaderOptions configures NewCsvReaderWithOptions.
+type CsvReaderOptions struct {
+	// CommentChar, when non-zero, skips lines beginning with this character.
+	// It is forwarded directly to csv.Reader.Comment.
+	CommentChar rune
+	// RequiredColumns lists headers that must be present; opening fails if any are missing.
+	RequiredColumns []string
+	// SkipBlankRecords skips data records whose fields are all empty or whitespace
+	// (such as ",,," separator lines). Skipped records do not advance the record number;
+	// line numbers still refer to physical lines.
+	SkipBlankRecords bool
+	// TrimValues strips leading and trailing whitespace from every cell before
+	// getters see it, so " 42 " parses as 42. Headers are always trimmed.
+	TrimValues bool
+}
+
+// NewCsvReader creates a new CSV reader from a file path.
+func NewCsvReader(path string) (*CsvReader, error) {
+	return NewCsvReaderWithOptions(path, CsvReaderOptions{})
+}
+
+// NewCsvReaderExpecting creates a new CSV reader and fails with an error listing
+// every required column missing from the header row.
+func NewCsvReaderExpecting(path string, required []string) (*CsvReader, error) {
+	return NewCsvReaderWithOptions(path, CsvReaderOptions{RequiredColumns: required})
+}
+
+// NewCsvReaderWithOptions creates a new CSV reader from a file path using opts.
+func NewCsvReaderWithOptions(path string, opts CsvReaderOptions) (*CsvReader, error) {
+	return NewCsvReaderWithOptionsContext(context.Background(), path, opts)
+}
+
+// NewCsvReaderContext is NewCsvReader honouring ctx: once ctx is done,
+// opening and every subsequent read fail with ctx.Err().
+func NewCsvReaderContext(ctx context.Context, path string) (*CsvReader, error) {
+	return NewCsvReaderWithOptionsContext(ctx, path, CsvReaderOptions{})
+}
+
+// NewCsvReaderWithOptionsContext is NewCsvReaderWithOptions honouring ctx.
+func NewCsvReaderWithOptionsContext(ctx context.Context, path string, opts CsvReaderOptions) (*CsvReader, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Strip a UTF-8 BOM (as written by Excel) so it does not end up in the first header.
+	buffered := bufio.NewReader(contextReader(ctx, file))
+	if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comment = opts.CommentChar
 
 	// Read header row
 	headerRow, err := reader.Read()
@@ -513,65 +1787,1108 @@ func NewCsvReader(path string) (*CsvReader, error) {
 		headers[strings.TrimSpace(h)] = i
 	}
 
+	var missing []string
+	for _, column := range opts.RequiredColumns {
+		if _, ok := headers[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		file.Close()
+		return nil, fmt.Errorf("%s: missing required CSV columns: %s", path, strings.Join(missing, ", "))
+	}
+
 	return &CsvReader{
-		headers: headers,
-		reader:  reader,
-		file:    file,
+		headers:    headers,
+		reader:     reader,
+		file:       file,
+		skipBlanks: opts.SkipBlankRecords,
+		trimValues: opts.TrimValues,
+		ctx:        ctx,
 	}, nil
 }
 
+// SetErrorHandler makes ReadAll and ReadBatch skip malformed rows, reporting each
+// to fn with its 1-based data record number (header excluded, as in CsvRow.RecordNumber)
+// instead of aborting. I/O errors still abort.
+func (r *CsvReader) SetErrorHandler(fn func(rowNum int, err error)) {
+	r.onError = fn
+}
+
+// SetBoolTokens replaces the values GetBool and TryGetBool accept as true and false
+// for every row from this reader. Matching is case-insensitive. A nil or empty truthy
+// or falsy argument restores the default set (true/1/yes or false/0/no) for that side,
+// so SetBoolTokens(nil, nil) resets both.
+func (r *CsvReader) SetBoolTokens(truthy, falsy []string) {
+	lower := func(tokens, defaults []string) []string {
+		if len(tokens) == 0 {
+			return defaults
+		}
+		out := make([]string, len(tokens))
+		for i, token := range tokens {
+			out[i] = strings.ToLower(strings.TrimSpace(token))
+		}
+		return out
+	}
+	r.bools = csvBoolTokens{
+		truthy: lower(truthy, defaultCsvBoolTokens.truthy),
+		falsy:  lower(falsy, defaultCsvBoolTokens.falsy),
+	}
+}
+
 // ReadRow reads the next row from the CSV file.
 func (r *CsvReader) ReadRow() (*CsvRow, error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
+	var row *CsvRow
+	var err error
+	if r.peeked {
+		row, err = r.peekRow, r.peekErr
+		r.peeked, r.peekRow, r.peekErr = false, nil, nil
+	} else {
+		row, err = r.readRow()
+	}
+	if row != nil {
+		r.line = row.line
+	}
+	return row, err
+}
+
+// LineNumber returns the 1-based physical line where the row most recently returned by
+// ReadRow starts, or 0 before the first row. Use it to fill ValidationError.LineNumber.
+// It differs from the data record number (CsvRow.RecordNumber, SetErrorHandler's rowNum)
+// when the file has quoted multi-line fields, comments or skipped blank records.
+func (r *CsvReader) LineNumber() int {
+	return r.line
+}
+
+// Peek returns the next row without consuming it; the following ReadRow returns the same row.
+// Peek on an exhausted reader returns (nil, io.EOF).
+func (r *CsvReader) Peek() (*CsvRow, error) {
+	if !r.peeked {
+		r.peekRow, r.peekErr = r.readRow()
+		r.peeked = true
+	}
+	return r.peekRow, r.peekErr
+}
+
+func (r *CsvReader) readRow() (*CsvRow, error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
 	values, err := r.reader.Read()
+	// A whitespace-only line parses as a single field, so tolerate the field count error for it.
+	for r.skipBlanks && (err == nil || errors.Is(err, csv.ErrFieldCount)) && csvRecordBlank(values) {
+		values, err = r.reader.Read()
+	}
+	if err == io.EOF {
+		return nil, err
+	}
+	r.rowNum++
+	if err != nil {
+		return nil, err
+	}
+	if r.trimValues {
+		for i, v := range values {
+			values[i] = strings.TrimSpace(v)
+		}
+	}
+	line, _ := r.reader.FieldPos(0)
+	return &CsvRow{headers: r.headers, values: values, record: r.rowNum, line: line, bools: &r.bools}, nil
+}
+
+func csvRecordBlank(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// nextRow reads the next row, passing malformed rows to the error handler when one is set.
+func (r *CsvReader) nextRow() (*CsvRow, error) {
+	for {
+		row, err := r.ReadRow()
+		var parseErr *csv.ParseError
+		if err != nil && r.onError != nil && errors.As(err, &parseErr) {
+			r.onError(r.rowNum, err)
+			continue
+		}
+		return row, err
+	}
+}
+
+// ReadAll reads all remaining rows from the CSV file.
+func (r *CsvReader) ReadAll() ([]*CsvRow, error) {
+	var rows []*CsvRow
+	for {
+		row, err := r.nextRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// MapAll reads all remaining rows and converts each with mapFn, stopping at the first
+// mapping error, which is returned wrapped with the row's line number.
+func MapAll[T any](r *CsvReader, mapFn func(*CsvRow) (T, error)) ([]T, error) {
+	var result []T
+	for {
+		row, err := r.nextRow()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value, err := mapFn(row)
+		if err != nil {
+			return nil, fmt.Errorf("CSV line %d: %w", row.LineNumber(), err)
+		}
+		result = append(result, value)
+	}
+}
+
+// DecodeCSVInto opens path, converts every row with mapFn, and closes the file.
+// Errors are prefixed with the path; mapping errors also carry the row's line number.
+func DecodeCSVInto[T any](path string, mapFn func(*CsvRow) (T, error)) ([]T, error) {
+	reader, err := NewCsvReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	values, err := MapAll(reader, mapFn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// StreamCSVInto returns an iterator over path's rows converted with mapFn. The file is
+// opened when iteration starts and closed when it ends. The first error (opening, reading,
+// or mapping) is yielded once and ends the iteration.
+func StreamCSVInto[T any](path string, mapFn func(*CsvRow) (T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		reader, err := NewCsvReader(path)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer reader.Close()
+		for {
+			row, err := reader.nextRow()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(zero, fmt.Errorf("%s: %w", path, err))
+				return
+			}
+			value, err := mapFn(row)
+			if err != nil {
+				yield(zero, fmt.Errorf("%s: CSV line %d: %w", path, row.LineNumber(), err))
+				return
+			}
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ReadBatch reads up to n rows from the CSV file.
+// A partial batch at end of file is returned with a nil error;
+// the following call returns (nil, io.EOF).
+func (r *CsvReader) ReadBatch(n int) ([]*CsvRow, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("batch size must be positive, got %d", n)
+	}
+	rows := make([]*CsvRow, 0, n)
+	for len(rows) < n {
+		row, err := r.nextRow()
+		if err == io.EOF {
+			if len(rows) == 0 {
+				return nil, io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Close closes the CSV reader.
+func (r *CsvReader) Close() error {
+	return r.file.Close()
+}
+
+// MergeCsvByKey applies patch rows on top of base rows matched by keyColumn.
+// Base rows without a matching patch row are kept in order, matching base rows are
+// replaced in place by the whole patch row, and unmatched patch rows are appended.
+// Rows are replaced, not merged cell by cell: a replaced row keeps the patch row's
+// own headers, so columns present only in base are dropped for that row and the
+// result may mix rows with different column sets. Every row must contain keyColumn
+// and keys must be unique within base and within patch.
+func MergeCsvByKey(base, patch []*CsvRow, keyColumn string) ([]*CsvRow, error) {
+	patchByKey := make(map[string]*CsvRow, len(patch))
+	patchOrder := make([]string, 0, len(patch))
+	for i, row := range patch {
+		key, ok := row.Get(keyColumn)
+		if !ok {
+			return nil, fmt.Errorf("patch row %d is missing key column %q", i+1, keyColumn)
+		}
+		if _, dup := patchByKey[key]; dup {
+			return nil, fmt.Errorf("duplicate patch key %q in column %q", key, keyColumn)
+		}
+		patchByKey[key] = row
+		patchOrder = append(patchOrder, key)
+	}
+
+	merged := make([]*CsvRow, 0, len(base)+len(patch))
+	seen := make(map[string]bool, len(base))
+	for i, row := range base {
+		key, ok := row.Get(keyColumn)
+		if !ok {
+			return nil, fmt.Errorf("base row %d is missing key column %q", i+1, keyColumn)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate base key %q in column %q", key, keyColumn)
+		}
+		seen[key] = true
+		if replacement, ok := patchByKey[key]; ok {
+			merged = append(merged, replacement)
+		} else {
+			merged = append(merged, row)
+		}
+	}
+	for _, key := range patchOrder {
+		if !seen[key] {
+			merged = append(merged, patchByKey[key])
+		}
+	}
+	return merged, nil
+}
+
+// contextReader wraps r so each Read first checks ctx, unless ctx can never be cancelled.
+func contextReader(ctx context.Context, r io.Reader) io.Reader {
+	if ctx.Done() == nil {
+		return r
+	}
+	return &ctxReader{ctx: ctx, reader: r}
+}
+
+type ctxReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// ============ CSV Writing ============
+
+// CsvWriter writes CSV files with a header row.
+type CsvWriter struct {
+	headers []string
+	columns map[string]int
+	writer  *csv.Writer
+	file    *os.File
+}
+
+// NewCsvWriter creates a CSV file at path and writes the header row.
+func NewCsvWriter(path string, headers []string) (*CsvWriter, error) {
+	file, err := os.Create(path)
 	if err != nil {
 		return nil, err
 	}
-	return &CsvRow{headers: r.headers, values: values}, nil
+	w, err := NewCsvWriterFromWriter(file, headers)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w.file = file
+	return w, nil
+}
+
+// NewCsvWriterFromWriter creates a CSV writer over w and writes the header row.
+func NewCsvWriterFromWriter(w io.Writer, headers []string) (*CsvWriter, error) {
+	columns := make(map[string]int, len(headers))
+	for i, h := range headers {
+		if _, ok := columns[h]; ok {
+			return nil, fmt.Errorf("duplicate CSV header %q", h)
+		}
+		columns[h] = i
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(headers); err != nil {
+		return nil, err
+	}
+	return &CsvWriter{
+		headers: append([]string(nil), headers...),
+		columns: columns,
+		writer:  writer,
+	}, nil
+}
+
+// WriteRow writes a row from values keyed by column name.
+// Columns missing from values are written as empty cells.
+func (w *CsvWriter) WriteRow(values map[string]string) error {
+	record := make([]string, len(w.headers))
+	for column, value := range values {
+		idx, ok := w.columns[column]
+		if !ok {
+			return fmt.Errorf("unknown CSV column %q", column)
+		}
+		record[idx] = value
+	}
+	return w.writer.Write(record)
+}
+
+// WriteValues writes a row from values in header order.
+func (w *CsvWriter) WriteValues(values []string) error {
+	if len(values) != len(w.headers) {
+		return fmt.Errorf("expected %d CSV values but got %d", len(w.headers), len(values))
+	}
+	return w.writer.Write(values)
+}
+
+// Close flushes buffered rows and closes the file if the writer owns one.
+func (w *CsvWriter) Close() error {
+	w.writer.Flush()
+	err := w.writer.Error()
+	if w.file != nil {
+		if closeErr := w.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// ============ Compressed Files ============
+
+// OpenMaybeGzip opens path for reading, transparently decompressing it when the
+// content starts with the gzip magic bytes (regardless of a .gz extension).
+func OpenMaybeGzip(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReader(file)
+	magic, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return &gzipFileReader{Reader: gz, file: file}, nil
+	}
+	return &bufferedFileReader{Reader: buffered, file: file}, nil
+}
+
+type gzipFileReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (r *gzipFileReader) Close() error {
+	err := r.Reader.Close()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type bufferedFileReader struct {
+	*bufio.Reader
+	file *os.File
+}
+
+func (r *bufferedFileReader) Close() error {
+	return r.file.Close()
+}
+
+// ============ JSON Loading ============
+
+// LoadJSON loads a JSON file into the given target.
+func LoadJSON[T any](path string, target *T) error {
+	file, err := OpenMaybeGzip(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return DecodeJSON(file, target)
+}
+
+// ErrChecksumMismatch is returned (wrapped) by VerifyFileChecksum when the file's digest differs.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// VerifyFileChecksum checks that the SHA-256 of the file's raw bytes matches the
+// hex-encoded expectedSHA256 (case-insensitive).
+func VerifyFileChecksum(path string, expectedSHA256 string) error {
+	expected, err := decodeSHA256Hex(expectedSHA256)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	return compareChecksum(path, expected, hash.Sum(nil))
+}
+
+// LoadJSONVerified is LoadJSON with a VerifyFileChecksum check; target is untouched if the
+// checksum fails. The file is read once and the verified bytes are the ones decoded.
+func LoadJSONVerified[T any](path, sha256Hex string, target *T) error {
+	expected, err := decodeSHA256Hex(sha256Hex)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	actual := sha256.Sum256(data)
+	if err := compareChecksum(path, expected, actual[:]); err != nil {
+		return err
+	}
+	var reader io.Reader = bytes.NewReader(data)
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	return DecodeJSON(reader, target)
+}
+
+func decodeSHA256Hex(value string) ([]byte, error) {
+	decoded, err := hex.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, fmt.Errorf("invalid SHA-256 hex %q", value)
+	}
+	return decoded, nil
+}
+
+func compareChecksum(path string, expected, actual []byte) error {
+	if !bytes.Equal(actual, expected) {
+		return fmt.Errorf("%s: %w: expected %x, got %x", path, ErrChecksumMismatch, expected, actual)
+	}
+	return nil
+}
+
+// LoadJSONContext is LoadJSON honouring ctx: it fails with ctx.Err() if ctx is done
+// before the file is opened or while it is being read.
+func LoadJSONContext[T any](ctx context.Context, path string, target *T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	file, err := OpenMaybeGzip(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return DecodeJSON(contextReader(ctx, file), target)
+}
+
+// LoadJSONSlice loads a JSON array file into a slice.
+func LoadJSONSlice[T any](path string) ([]T, error) {
+	file, err := OpenMaybeGzip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodeJSONSlice[T](file)
+}
+
+// LoadJSONFromReader loads JSON from r into the given target. It is the reader
+// counterpart of LoadJSON and behaves exactly like DecodeJSON.
+func LoadJSONFromReader[T any](r io.Reader, target *T) error {
+	return DecodeJSON(r, target)
+}
+
+// LoadJSONSliceFromReader loads a JSON array from r into a slice. It is the reader
+// counterpart of LoadJSONSlice and behaves exactly like DecodeJSONSlice.
+func LoadJSONSliceFromReader[T any](r io.Reader) ([]T, error) {
+	return DecodeJSONSlice[T](r)
+}
+
+// LoadJSONMap loads a JSON object file whose top-level keys are record IDs into a map.
+// K follows encoding/json map key rules: string kinds are used as-is, integer kinds are
+// parsed from numeric key strings, and encoding.TextUnmarshaler types decode themselves.
+// Other key types return a decode error.
+func LoadJSONMap[K comparable, V any](path string) (map[K]V, error) {
+	file, err := OpenMaybeGzip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadJSONMapFromReader[K, V](file)
+}
+
+// LoadJSONMapFromReader loads a JSON object from r into a map; see LoadJSONMap for key rules.
+func LoadJSONMapFromReader[K comparable, V any](r io.Reader) (map[K]V, error) {
+	var result map[K]V
+	if err := DecodeJSON(r, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadJSONFromBytes loads JSON from data into the given target.
+func LoadJSONFromBytes[T any](data []byte, target *T) error {
+	return json.Unmarshal(data, target)
+}
+
+// LoadJSONSliceFromBytes loads a JSON array from data into a slice.
+func LoadJSONSliceFromBytes[T any](data []byte) ([]T, error) {
+	var result []T
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadJSONFS loads a JSON file from fsys (for example an embed.FS) into the given target.
+func LoadJSONFS[T any](fsys fs.FS, path string, target *T) error {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return DecodeJSON(file, target)
+}
+
+// LoadJSONSliceFS loads a JSON array file from fsys (for example an embed.FS) into a slice.
+func LoadJSONSliceFS[T any](fsys fs.FS, path string) ([]T, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodeJSONSlice[T](file)
+}
+
+// LoadJSONStrict loads a JSON file into the given target, rejecting object keys
+// that do not match a field of the target type. The error names the offending field.
+func LoadJSONStrict[T any](path string, target *T) error {
+	file, err := OpenMaybeGzip(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return decodeJSON(file, target, true)
+}
+
+// LoadJSONSliceStrict loads a JSON array file into a slice, rejecting object keys
+// that do not match a field of T, as LoadJSONStrict does.
+func LoadJSONSliceStrict[T any](path string) ([]T, error) {
+	file, err := OpenMaybeGzip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var result []T
+	if err := decodeJSON(file, &result, true); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DecodeJSON decodes a single JSON value from r into the given target.
+// Data after the value is rejected, matching json.Unmarshal.
+func DecodeJSON[T any](r io.Reader, target *T) error {
+	return decodeJSON(r, target, false)
+}
+
+func decodeJSON[T any](r io.Reader, target *T, strict bool) error {
+	decoder := json.NewDecoder(r)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(target); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			return fmt.Errorf("unexpected data after top-level JSON value")
+		}
+		return err
+	}
+	return nil
+}
+
+// DecodeJSONSlice decodes a JSON array from r into a slice.
+func DecodeJSONSlice[T any](r io.Reader) ([]T, error) {
+	var result []T
+	if err := DecodeJSON(r, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SaveJSONOptions controls how SaveJSONWithOptions formats its output.
+type SaveJSONOptions struct {
+	// Compact writes JSON without indentation, ignoring Prefix and Indent.
+	Compact bool
+	// Prefix is written at the start of every indented line.
+	Prefix string
+	// Indent is the per-level indentation; empty means two spaces.
+	Indent string
+}
+
+// SaveJSON writes value to path as two-space indented JSON.
+// The file is replaced atomically so a crash mid-write never leaves a partial file.
+func SaveJSON[T any](path string, value T) error {
+	return SaveJSONWithOptions(path, value, SaveJSONOptions{})
+}
+
+// SaveJSONSlice writes values to path as a two-space indented JSON array.
+func SaveJSONSlice[T any](path string, values []T) error {
+	return SaveJSONSliceWithOptions(path, values, SaveJSONOptions{})
+}
+
+// SaveJSONWithOptions writes value to path as JSON formatted according to opts.
+func SaveJSONWithOptions[T any](path string, value T, opts SaveJSONOptions) error {
+	var data []byte
+	var err error
+	if opts.Compact {
+		data, err = json.Marshal(value)
+	} else {
+		indent := opts.Indent
+		if indent == "" {
+			indent = "  "
+		}
+		data, err = json.MarshalIndent(value, opts.Prefix, indent)
+	}
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// SaveJSONGzip writes value to path as gzip-compressed, two-space indented JSON.
+// The result can be read back by LoadJSON and the other path-based JSON loaders.
+func SaveJSONGzip[T any](path string, value T) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// SaveJSONSliceWithOptions writes values to path as a JSON array formatted according to opts.
+// A nil slice is written as an empty array.
+func SaveJSONSliceWithOptions[T any](path string, values []T, opts SaveJSONOptions) error {
+	if values == nil {
+		values = []T{}
+	}
+	return SaveJSONWithOptions(path, values, opts)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// StreamJSONSlice decodes a JSON array file one element at a time, calling yield
+// for each element until it returns false, without loading the whole array.
+func StreamJSONSlice[T any](path string, yield func(T) bool) error {
+	file, err := OpenMaybeGzip(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := streamJSONArray(json.NewDecoder(bufio.NewReader(file)), yield); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadJSONStream decodes a JSON array file one element at a time, calling fn for each.
+// If fn returns an error, streaming stops and the error is returned wrapped with the element index.
+func LoadJSONStream[T any](path string, fn func(T) error) error {
+	var fnErr error
+	index := 0
+	err := StreamJSONSlice(path, func(value T) bool {
+		if err := fn(value); err != nil {
+			fnErr = fmt.Errorf("%s: JSON array element %d: %w", path, index, err)
+			return false
+		}
+		index++
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return fnErr
+}
+
+// LoadJSONChannel streams a JSON array file from a goroutine, sending each element on
+// the returned channel (buffered by bufSize) and closing it when done. At most one error
+// is delivered on the error channel, which is closed after the element channel.
+// A consumer that stops ranging early must cancel ctx; the goroutine then closes the file
+// and both channels, reporting ctx.Err().
+func LoadJSONChannel[T any](ctx context.Context, path string, bufSize int) (<-chan T, <-chan error) {
+	if bufSize < 0 {
+		bufSize = 0
+	}
+	items := make(chan T, bufSize)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		cancelled := false
+		err := StreamJSONSlice(path, func(value T) bool {
+			select {
+			case items <- value:
+				return true
+			case <-ctx.Done():
+				cancelled = true
+				return false
+			}
+		})
+		close(items)
+		if err == nil && cancelled {
+			err = ctx.Err()
+		}
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return items, errs
+}
+
+func streamJSONArray[T any](decoder *json.Decoder, yield func(T) bool) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("reading JSON array start: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected JSON array but found %v", token)
+	}
+	for index := 0; decoder.More(); index++ {
+		var value T
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("decoding JSON array element %d: %w", index, err)
+		}
+		if !yield(value) {
+			return nil
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("reading JSON array end: %w", err)
+	}
+	return nil
+}
+
+// LoadJSONLines loads a newline-delimited JSON file (one value per line) into a slice.
+func LoadJSONLines[T any](path string) ([]T, error) {
+	var result []T
+	err := StreamJSONLines(path, func(value T) bool {
+		result = append(result, value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StreamJSONLines decodes a newline-delimited JSON file one line at a time,
+// calling yield for each value until it returns false. Blank lines are skipped.
+func StreamJSONLines[T any](path string, yield func(T) bool) error {
+	file, err := OpenMaybeGzip(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var value T
+			if err := json.Unmarshal(trimmed, &value); err != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+			if !yield(value) {
+				return nil
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// ============ Load Profiling ============
+
+// LoadProfileEntry records how long loading a single file took.
+type LoadProfileEntry struct {
+	Path     string
+	Duration time.Duration
+	Bytes    int64
+}
+
+// LoadProfile accumulates per-file load timings. It is safe for concurrent use.
+type LoadProfile struct {
+	mu      sync.Mutex
+	Entries []LoadProfileEntry
+}
+
+// NewLoadProfile creates an empty load profile.
+func NewLoadProfile() *LoadProfile {
+	return &LoadProfile{}
+}
+
+// Add records an entry in the profile.
+func (p *LoadProfile) Add(entry LoadProfileEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Entries = append(p.Entries, entry)
+}
+
+// Print writes a table of entries sorted by duration, slowest first.
+func (p *LoadProfile) Print(w io.Writer) error {
+	p.mu.Lock()
+	entries := append([]LoadProfileEntry(nil), p.Entries...)
+	p.mu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Duration > entries[j].Duration })
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tDURATION\tBYTES")
+	var total time.Duration
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", entry.Path, entry.Duration, entry.Bytes)
+		total += entry.Duration
+	}
+	fmt.Fprintf(tw, "TOTAL\t%s\t\n", total)
+	return tw.Flush()
+}
+
+// ProfiledLoad runs load(path) and records its duration and the file size in profile.
+// Use it to wrap generated loaders such as Load<Table>sFromBinary. A nil profile only loads.
+func ProfiledLoad(path string, profile *LoadProfile, load func(path string) error) error {
+	start := time.Now()
+	err := load(path)
+	if profile != nil {
+		entry := LoadProfileEntry{Path: path, Duration: time.Since(start)}
+		if info, statErr := os.Stat(path); statErr == nil {
+			entry.Bytes = info.Size()
+		}
+		profile.Add(entry)
+	}
+	return err
+}
+
+// ProfiledLoadJSON runs LoadJSON and records the load in profile.
+func ProfiledLoadJSON[T any](path string, target *T, profile *LoadProfile) error {
+	return ProfiledLoad(path, profile, func(path string) error {
+		return LoadJSON(path, target)
+	})
+}
+
+// ============ Table Registry ============
+
+// TableStatus is the lifecycle state of a table in a TableRegistry.
+type TableStatus int
+
+const (
+	// TableStatusUnloaded means the table is registered (or unknown) but not loaded yet.
+	TableStatusUnloaded TableStatus = iota
+	// TableStatusLoading means the table's loader is running.
+	TableStatusLoading
+	// TableStatusLoaded means the last load succeeded.
+	TableStatusLoaded
+	// TableStatusError means the last load failed; see TableRegistry.Err.
+	TableStatusError
+)
+
+func (s TableStatus) String() string {
+	switch s {
+	case TableStatusUnloaded:
+		return "Unloaded"
+	case TableStatusLoading:
+		return "Loading"
+	case TableStatusLoaded:
+		return "Loaded"
+	case TableStatusError:
+		return "Error"
+	default:
+		return "Unknown"
+	}
+}
+
+type tableRegistryEntry struct {
+	loader func() error
+	status TableStatus
+	err    error
+	// loadMu serialises loads of this table without holding the registry lock.
+	loadMu sync.Mutex
+}
+
+// TableRegistry tracks the load state of named tables. It is safe for concurrent use.
+type TableRegistry struct {
+	mu      sync.RWMutex
+	order   []string
+	entries map[string]*tableRegistryEntry
+}
+
+// NewTableRegistry creates an empty table registry.
+func NewTableRegistry() *TableRegistry {
+	return &TableRegistry{entries: make(map[string]*tableRegistryEntry)}
+}
+
+// Register adds a table and its loader, such as a closure over a generated
+// Load<Table>sFromCsv call. Registering an existing name replaces its loader and
+// resets it to unloaded.
+func (reg *TableRegistry) Register(name string, loader func() error) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.entries[name]; !ok {
+		reg.order = append(reg.order, name)
+	}
+	reg.entries[name] = &tableRegistryEntry{loader: loader}
+}
+
+// Load runs the table's loader unless it is already loaded.
+func (reg *TableRegistry) Load(name string) error {
+	return reg.load(name, false)
 }
 
-// ReadAll reads all remaining rows from the CSV file.
-func (r *CsvReader) ReadAll() ([]*CsvRow, error) {
-	var rows []*CsvRow
-	for {
-		row, err := r.ReadRow()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, err
+// Reload runs the table's loader even if it is already loaded.
+func (reg *TableRegistry) Reload(name string) error {
+	return reg.load(name, true)
+}
+
+// LoadAll loads every table not yet loaded, in registration order. It keeps going past
+// failures and returns them joined, each prefixed with the table name.
+func (reg *TableRegistry) LoadAll() error {
+	reg.mu.RLock()
+	names := slices.Clone(reg.order)
+	reg.mu.RUnlock()
+	var errs []error
+	for _, name := range names {
+		if err := reg.Load(name); err != nil {
+			errs = append(errs, err)
 		}
-		rows = append(rows, row)
 	}
-	return rows, nil
+	return errors.Join(errs...)
 }
 
-// Close closes the CSV reader.
-func (r *CsvReader) Close() error {
-	return r.file.Close()
+// Status returns the table's state; unregistered names report TableStatusUnloaded.
+func (reg *TableRegistry) Status(name string) TableStatus {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if entry, ok := reg.entries[name]; ok {
+		return entry.status
+	}
+	return TableStatusUnloaded
 }
 
-// ============ JSON Loading ============
-
-// LoadJSON loads a JSON file into the given target.
-func LoadJSON[T any](path string, target *T) error {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return err
+// Err returns the error from the table's last failed load, or nil.
+func (reg *TableRegistry) Err(name string) error {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if entry, ok := reg.entries[name]; ok {
+		return entry.err
 	}
-	return json.Unmarshal(data, target)
+	return nil
 }
 
-// LoadJSONSlice loads a JSON array file into a slice.
-func LoadJSONSlice[T any](path string) ([]T, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+func (reg *TableRegistry) load(name string, force bool) error {
+	reg.mu.RLock()
+	entry, ok := reg.entries[name]
+	reg.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("table %q is not registered", name)
 	}
-	var result []T
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
+
+	entry.loadMu.Lock()
+	defer entry.loadMu.Unlock()
+	reg.mu.Lock()
+	if entry.status == TableStatusLoaded && !force {
+		reg.mu.Unlock()
+		return nil
 	}
-	return result, nil
+	entry.status = TableStatusLoading
+	reg.mu.Unlock()
+
+	err := entry.loader()
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if err != nil {
+		err = fmt.Errorf("table %q: %w", name, err)
+		entry.status, entry.err = TableStatusError, err
+		return err
+	}
+	entry.status, entry.err = TableStatusLoaded, nil
+	return nil
 }
 
 // ============ Binary I/O ============
@@ -580,6 +2897,7 @@ func LoadJSONSlice[T any](path string) ([]T, error) {
 type BinaryReader struct {
 	reader io.Reader
 	order  binary.ByteOrder
+	closer io.Closer
 }
 
 // NewBinaryReader creates a new binary reader with little-endian byte order.
@@ -587,6 +2905,73 @@ func NewBinaryReader(reader io.Reader) *BinaryReader {
 	return &BinaryReader{reader: reader, order: binary.LittleEndian}
 }
 
+// NewTeeReader returns a reader over the same source as r that copies every byte
+// it reads to w, so raw bytes can be logged alongside parsed values.
+// The returned reader does not own r's source; close r itself when done.
+func NewTeeReader(r *BinaryReader, w io.Writer) *BinaryReader {
+	return &BinaryReader{reader: io.TeeReader(r.reader, w), order: r.order}
+}
+
+// NewBufferedBinaryReader creates a little-endian binary reader over a bufio.Reader,
+// which enables Peek on sources that cannot seek.
+func NewBufferedBinaryReader(reader io.Reader) *BinaryReader {
+	return NewBinaryReader(bufio.NewReader(reader))
+}
+
+// Peek returns the next n bytes without consuming them. The reader must come from
+// NewBufferedBinaryReader (or wrap a *bufio.Reader) or be backed by an io.ReadSeeker.
+func (r *BinaryReader) Peek(n int) ([]byte, error) {
+	switch source := r.reader.(type) {
+	case *bufio.Reader:
+		return source.Peek(n)
+	case io.ReadSeeker:
+		buf := make([]byte, n)
+		read, err := io.ReadFull(source, buf)
+		if _, seekErr := source.Seek(int64(-read), io.SeekCurrent); seekErr != nil {
+			return nil, seekErr
+		}
+		return buf[:read], err
+	default:
+		return nil, errors.New("peek requires a buffered or seekable BinaryReader; use NewBufferedBinaryReader")
+	}
+}
+
+// Seek passes through to the underlying reader when it implements io.Seeker.
+func (r *BinaryReader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := r.reader.(io.Seeker)
+	if !ok {
+		return 0, errors.New("seek requires an io.Seeker-backed BinaryReader")
+	}
+	return seeker.Seek(offset, whence)
+}
+
+// ReadZip opens the zip archive in r and returns a reader over the named entry
+// without extracting it. Close the returned reader to release the entry.
+func ReadZip(r io.ReaderAt, size int64, entryName string) (*BinaryReader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := archive.Open(entryName)
+	if err != nil {
+		return nil, fmt.Errorf("zip entry %q: %w", entryName, err)
+	}
+	reader := NewBinaryReader(entry)
+	reader.closer = entry
+	return reader, nil
+}
+
+// Close releases the underlying source when the reader owns one (such as a zip entry);
+// otherwise it does nothing.
+func (r *BinaryReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	err := r.closer.Close()
+	r.closer = nil
+	return err
+}
+
 // ReadUint8 reads a uint8.
 func (r *BinaryReader) ReadUint8() (uint8, error) {
 	var val uint8
@@ -615,6 +3000,42 @@ func (r *BinaryReader) ReadUint64() (uint64, error) {
 	return val, err
 }
 
+// ReadUint128 reads a 128-bit integer as two uint64 halves, high half first;
+// each half uses the reader's byte order.
+func (r *BinaryReader) ReadUint128() ([2]uint64, error) {
+	var val [2]uint64
+	err := binary.Read(r.reader, r.order, &val)
+	return val, err
+}
+
+// ReadUint16BE reads a big-endian uint16 regardless of the reader's byte order.
+func (r *BinaryReader) ReadUint16BE() (uint16, error) {
+	var val uint16
+	err := binary.Read(r.reader, binary.BigEndian, &val)
+	return val, err
+}
+
+// ReadUint32BE reads a big-endian uint32 regardless of the reader's byte order.
+func (r *BinaryReader) ReadUint32BE() (uint32, error) {
+	var val uint32
+	err := binary.Read(r.reader, binary.BigEndian, &val)
+	return val, err
+}
+
+// ReadUint64BE reads a big-endian uint64 regardless of the reader's byte order.
+func (r *BinaryReader) ReadUint64BE() (uint64, error) {
+	var val uint64
+	err := binary.Read(r.reader, binary.BigEndian, &val)
+	return val, err
+}
+
+// ReadInt32BE reads a big-endian int32 regardless of the reader's byte order.
+func (r *BinaryReader) ReadInt32BE() (int32, error) {
+	var val int32
+	err := binary.Read(r.reader, binary.BigEndian, &val)
+	return val, err
+}
+
 // ReadInt8 reads an int8.
 func (r *BinaryReader) ReadInt8() (int8, error) {
 	var val int8
@@ -677,6 +3098,99 @@ func (r *BinaryReader) ReadString() (string, error) {
 	return string(bytes), nil
 }
 
+// ReadPascalString reads a string with a uint8 length prefix (at most 255 bytes).
+func (r *BinaryReader) ReadPascalString() (string, error) {
+	length, err := r.ReadUint8()
+	if err != nil {
+		return "", err
+	}
+	bytes := make([]byte, length)
+	_, err = io.ReadFull(r.reader, bytes)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// ReadPadded reads exactly width bytes and strips all trailing stripByte bytes.
+func (r *BinaryReader) ReadPadded(width int, stripByte byte) (string, error) {
+	if width < 0 {
+		return "", fmt.Errorf("negative padded field width %d", width)
+	}
+	bytes := make([]byte, width)
+	if _, err := io.ReadFull(r.reader, bytes); err != nil {
+		return "", err
+	}
+	end := len(bytes)
+	for end > 0 && bytes[end-1] == stripByte {
+		end--
+	}
+	return string(bytes[:end]), nil
+}
+
+// ReadSliceBool reads a uint32 count followed by one uint8 per bool.
+func (r *BinaryReader) ReadSliceBool() ([]bool, error) {
+	count, err := r.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+	raw := make([]byte, count)
+	if _, err := io.ReadFull(r.reader, raw); err != nil {
+		return nil, err
+	}
+	values := make([]bool, count)
+	for i, b := range raw {
+		values[i] = b != 0
+	}
+	return values, nil
+}
+
+// ReadBitmask reads count bools packed eight per byte, least significant bit first.
+func (r *BinaryReader) ReadBitmask(count int) ([]bool, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("negative bitmask length %d", count)
+	}
+	raw := make([]byte, (count+7)/8)
+	if _, err := io.ReadFull(r.reader, raw); err != nil {
+		return nil, err
+	}
+	values := make([]bool, count)
+	for i := range values {
+		values[i] = raw[i/8]&(1<<(i%8)) != 0
+	}
+	return values, nil
+}
+
+// ReadOptionalStringMap reads a presence byte and, when it is 1, a map written by
+// WriteOptionalStringMap. An absent map is returned as nil; a present empty map as non-nil.
+func (r *BinaryReader) ReadOptionalStringMap() (map[string]string, error) {
+	present, err := r.ReadBool()
+	if err != nil || !present {
+		return nil, err
+	}
+	readString := func(r *BinaryReader) (string, error) { return r.ReadString() }
+	return ReadMap(r, readString, readString)
+}
+
+// ReadCString reads a null-terminated string, consuming the terminator.
+// A stream that ends before the terminator yields io.ErrUnexpectedEOF.
+func (r *BinaryReader) ReadCString() (string, error) {
+	var buf []byte
+	var b [1]byte
+	for {
+		if _, err := io.ReadFull(r.reader, b[:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", fmt.Errorf("reading null-terminated string: %w", err)
+		}
+		if b[0] == 0 {
+			return string(buf), nil
+		}
+		buf = append(buf, b[0])
+	}
+}
+
 // ReadBytes reads a length-prefixed byte slice.
 func (r *BinaryReader) ReadBytes() ([]byte, error) {
 	length, err := r.ReadUint32()
@@ -691,6 +3205,35 @@ func (r *BinaryReader) ReadBytes() ([]byte, error) {
 	return bytes, nil
 }
 
+// ReadInt32Array reads exactly n int32 values with no length prefix.
+func (r *BinaryReader) ReadInt32Array(n int) ([]int32, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("negative array length %d", n)
+	}
+	values := make([]int32, n)
+	if err := binary.Read(r.reader, r.order, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ReadArray reads exactly n elements with readElem, for fixed-size arrays whose
+// count comes from the schema rather than the stream.
+func ReadArray[T any](r *BinaryReader, n int, readElem func(*BinaryReader) (T, error)) ([]T, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("negative array length %d", n)
+	}
+	values := make([]T, 0, n)
+	for i := 0; i < n; i++ {
+		value, err := readElem(r)
+		if err != nil {
+			return nil, fmt.Errorf("array element %d: %w", i, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
 // BinaryWriter provides binary writing utilities.
 type BinaryWriter struct {
 	writer io.Writer
@@ -722,6 +3265,32 @@ func (w *BinaryWriter) WriteUint64(val uint64) error {
 	return binary.Write(w.writer, w.order, val)
 }
 
+// WriteUint128 writes a 128-bit integer as two uint64 halves (val[0] high, val[1] low),
+// high half first; each half uses the writer's byte order.
+func (w *BinaryWriter) WriteUint128(val [2]uint64) error {
+	return binary.Write(w.writer, w.order, val)
+}
+
+// WriteUint16BE writes a big-endian uint16 regardless of the writer's byte order.
+func (w *BinaryWriter) WriteUint16BE(val uint16) error {
+	return binary.Write(w.writer, binary.BigEndian, val)
+}
+
+// WriteUint32BE writes a big-endian uint32 regardless of the writer's byte order.
+func (w *BinaryWriter) WriteUint32BE(val uint32) error {
+	return binary.Write(w.writer, binary.BigEndian, val)
+}
+
+// WriteUint64BE writes a big-endian uint64 regardless of the writer's byte order.
+func (w *BinaryWriter) WriteUint64BE(val uint64) error {
+	return binary.Write(w.writer, binary.BigEndian, val)
+}
+
+// WriteInt32BE writes a big-endian int32 regardless of the writer's byte order.
+func (w *BinaryWriter) WriteInt32BE(val int32) error {
+	return binary.Write(w.writer, binary.BigEndian, val)
+}
+
 // WriteInt8 writes an int8.
 func (w *BinaryWriter) WriteInt8(val int8) error {
 	return binary.Write(w.writer, w.order, val)
@@ -737,52 +3306,334 @@ func (w *BinaryWriter) WriteInt32(val int32) error {
 	return binary.Write(w.writer, w.order, val)
 }
 
-// WriteInt64 writes an int64.
-func (w *BinaryWriter) WriteInt64(val int64) error {
-	return binary.Write(w.writer, w.order, val)
+// WriteInt64 writes an int64.
+func (w *BinaryWriter) WriteInt64(val int64) error {
+	return binary.Write(w.writer, w.order, val)
+}
+
+// WriteFloat32 writes a float32.
+func (w *BinaryWriter) WriteFloat32(val float32) error {
+	return binary.Write(w.writer, w.order, val)
+}
+
+// WriteFloat64 writes a float64.
+func (w *BinaryWriter) WriteFloat64(val float64) error {
+	return binary.Write(w.writer, w.order, val)
+}
+
+// WriteBool writes a bool (as uint8).
+func (w *BinaryWriter) WriteBool(val bool) error {
+	var b uint8
+	if val {
+		b = 1
+	}
+	return w.WriteUint8(b)
+}
+
+// WriteString writes a length-prefixed string.
+func (w *BinaryWriter) WriteString(val string) error {
+	if err := w.WriteUint32(uint32(len(val))); err != nil {
+		return err
+	}
+	_, err := w.writer.Write([]byte(val))
+	return err
+}
+
+// WritePascalString writes a string with a uint8 length prefix.
+// Strings longer than 255 bytes are rejected.
+func (w *BinaryWriter) WritePascalString(val string) error {
+	if len(val) > math.MaxUint8 {
+		return fmt.Errorf("pascal string length %d exceeds maximum %d", len(val), math.MaxUint8)
+	}
+	if err := w.WriteUint8(uint8(len(val))); err != nil {
+		return err
+	}
+	_, err := w.writer.Write([]byte(val))
+	return err
+}
+
+// WritePadded writes val as exactly width bytes, truncating longer values
+// and filling shorter ones with padByte.
+func (w *BinaryWriter) WritePadded(val string, width int, padByte byte) error {
+	if width < 0 {
+		return fmt.Errorf("negative padded field width %d", width)
+	}
+	buf := make([]byte, width)
+	n := copy(buf, val)
+	for i := n; i < width; i++ {
+		buf[i] = padByte
+	}
+	_, err := w.writer.Write(buf)
+	return err
+}
+
+// WriteSliceBool writes a uint32 count followed by one uint8 per bool.
+func (w *BinaryWriter) WriteSliceBool(vals []bool) error {
+	if err := w.WriteUint32(uint32(len(vals))); err != nil {
+		return err
+	}
+	raw := make([]byte, len(vals))
+	for i, val := range vals {
+		if val {
+			raw[i] = 1
+		}
+	}
+	_, err := w.writer.Write(raw)
+	return err
+}
+
+// WriteBitmask writes vals packed eight per byte, least significant bit first, with no
+// count prefix; the final byte is zero-padded.
+func (w *BinaryWriter) WriteBitmask(vals []bool) error {
+	raw := make([]byte, (len(vals)+7)/8)
+	for i, val := range vals {
+		if val {
+			raw[i/8] |= 1 << (i % 8)
+		}
+	}
+	_, err := w.writer.Write(raw)
+	return err
+}
+
+// WriteOptionalStringMap writes 0x00 for a nil map, or 0x01 followed by the entries
+// in ascending key order; an empty non-nil map is written as 0x01 and a zero count.
+func (w *BinaryWriter) WriteOptionalStringMap(m map[string]string) error {
+	if m == nil {
+		return w.WriteBool(false)
+	}
+	if err := w.WriteBool(true); err != nil {
+		return err
+	}
+	writeString := func(w *BinaryWriter, val string) error { return w.WriteString(val) }
+	return WriteMapSorted(w, m, writeString, writeString)
+}
+
+// WriteCString writes val followed by a 0x00 terminator.
+// Strings containing an embedded null byte are rejected.
+func (w *BinaryWriter) WriteCString(val string) error {
+	if strings.IndexByte(val, 0) >= 0 {
+		return fmt.Errorf("null-terminated string %q contains an embedded null byte", val)
+	}
+	_, err := w.writer.Write(append([]byte(val), 0))
+	return err
+}
+
+// WriteBytes writes a length-prefixed byte slice.
+func (w *BinaryWriter) WriteBytes(val []byte) error {
+	if err := w.WriteUint32(uint32(len(val))); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(val)
+	return err
+}
+
+// WriteRaw writes raw bytes without a length prefix.
+func (w *BinaryWriter) WriteRaw(val []byte) error {
+	_, err := w.writer.Write(val)
+	return err
+}
+
+// BinaryEncodable is implemented by types that can write themselves with a BinaryWriter.
+// Generated table types implement it on their pointer type.
+type BinaryEncodable interface {
+	WriteBinary(writer *BinaryWriter) error
+}
+
+// WriteLengthPrefixedStruct writes val prefixed by its encoded size as a uint32,
+// so readers can skip fields added by newer versions.
+func WriteLengthPrefixedStruct[T BinaryEncodable](w *BinaryWriter, val T) error {
+	var buf bytes.Buffer
+	if err := val.WriteBinary(&BinaryWriter{writer: &buf, order: w.order}); err != nil {
+		return err
+	}
+	if err := w.WriteUint32(uint32(buf.Len())); err != nil {
+		return err
+	}
+	return w.WriteRaw(buf.Bytes())
+}
+
+// ReadLengthPrefixedStruct reads a uint32 size and decodes a T from exactly that many bytes
+// with readFn, e.g. a generated Read<Type>Binary function.
+// Bytes left over after decoding are skipped for forward compatibility.
+func ReadLengthPrefixedStruct[T any](r *BinaryReader, readFn func(*BinaryReader) (T, error)) (T, error) {
+	var zero T
+	size, err := r.ReadUint32()
+	if err != nil {
+		return zero, err
+	}
+	limited := &io.LimitedReader{R: r.reader, N: int64(size)}
+	val, err := readFn(&BinaryReader{reader: limited, order: r.order})
+	if err != nil {
+		return zero, err
+	}
+	if _, err := io.Copy(io.Discard, limited); err != nil {
+		return val, err
+	}
+	if limited.N > 0 {
+		return val, io.ErrUnexpectedEOF
+	}
+	return val, nil
+}
+
+// WriteMap writes a uint32 entry count followed by each key and value. Entries are
+// written in Go map iteration order; use WriteMapSorted when output must be deterministic.
+func WriteMap[K comparable, V any](w *BinaryWriter, m map[K]V, writeK func(*BinaryWriter, K) error, writeV func(*BinaryWriter, V) error) error {
+	if err := w.WriteUint32(uint32(len(m))); err != nil {
+		return err
+	}
+	for key, value := range m {
+		if err := writeK(w, key); err != nil {
+			return err
+		}
+		if err := writeV(w, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMapSorted is WriteMap with entries written in ascending key order,
+// so equal maps always encode to identical bytes.
+func WriteMapSorted[K cmp.Ordered, V any](w *BinaryWriter, m map[K]V, writeK func(*BinaryWriter, K) error, writeV func(*BinaryWriter, V) error) error {
+	if err := w.WriteUint32(uint32(len(m))); err != nil {
+		return err
+	}
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if err := writeK(w, key); err != nil {
+			return err
+		}
+		if err := writeV(w, m[key]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// WriteFloat32 writes a float32.
-func (w *BinaryWriter) WriteFloat32(val float32) error {
-	return binary.Write(w.writer, w.order, val)
+// ReadMap reads a map written by WriteMap or WriteMapSorted. Duplicate keys are rejected.
+func ReadMap[K comparable, V any](r *BinaryReader, readK func(*BinaryReader) (K, error), readV func(*BinaryReader) (V, error)) (map[K]V, error) {
+	count, err := r.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[K]V, min(int(count), 1024))
+	for i := uint32(0); i < count; i++ {
+		key, err := readK(r)
+		if err != nil {
+			return nil, fmt.Errorf("map entry %d key: %w", i, err)
+		}
+		value, err := readV(r)
+		if err != nil {
+			return nil, fmt.Errorf("map entry %d value: %w", i, err)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("map entry %d: duplicate key %v", i, key)
+		}
+		m[key] = value
+	}
+	return m, nil
 }
 
-// WriteFloat64 writes a float64.
-func (w *BinaryWriter) WriteFloat64(val float64) error {
-	return binary.Write(w.writer, w.order, val)
-}
+const (
+	compactFull  uint8 = 0
+	compactDelta uint8 = 1
+)
 
-// WriteBool writes a bool (as uint8).
-func (w *BinaryWriter) WriteBool(val bool) error {
-	var b uint8
-	if val {
-		b = 1
+// WriteCompact writes val in full behind a compact-encoding marker byte, for streams
+// where a value has no previous value to delta against (e.g. the first row).
+func WriteCompact[T any](w *BinaryWriter, val T, writeFull func(*BinaryWriter, T) error) error {
+	if err := w.WriteUint8(compactFull); err != nil {
+		return err
 	}
-	return w.WriteUint8(b)
+	return writeFull(w, val)
 }
 
-// WriteString writes a length-prefixed string.
-func (w *BinaryWriter) WriteString(val string) error {
-	if err := w.WriteUint32(uint32(len(val))); err != nil {
+// WriteCompactWithBase writes val as a delta against base when writeDelta accepts it
+// (returns true), falling back to writeFull otherwise. A marker byte records which
+// encoding was used so ReadCompact/ReadCompactWithBase can decode either.
+func WriteCompactWithBase[T any](w *BinaryWriter, val, base T, writeFull func(*BinaryWriter, T) error, writeDelta func(w *BinaryWriter, val, base T) (bool, error)) error {
+	var buf bytes.Buffer
+	ok, err := writeDelta(&BinaryWriter{writer: &buf, order: w.order}, val, base)
+	if err != nil {
 		return err
 	}
-	_, err := w.writer.Write([]byte(val))
-	return err
+	if !ok {
+		return WriteCompact(w, val, writeFull)
+	}
+	if err := w.WriteUint8(compactDelta); err != nil {
+		return err
+	}
+	return w.WriteRaw(buf.Bytes())
 }
 
-// WriteBytes writes a length-prefixed byte slice.
-func (w *BinaryWriter) WriteBytes(val []byte) error {
-	if err := w.WriteUint32(uint32(len(val))); err != nil {
-		return err
+// ReadCompact reads a value written by WriteCompact. Delta-encoded values need a base,
+// so they are rejected here; use ReadCompactWithBase for those streams.
+func ReadCompact[T any](r *BinaryReader, readFull func(*BinaryReader) (T, error)) (T, error) {
+	var zero T
+	marker, err := r.ReadUint8()
+	if err != nil {
+		return zero, err
 	}
-	_, err := w.writer.Write(val)
-	return err
+	if marker != compactFull {
+		return zero, fmt.Errorf("unexpected compact encoding marker %d without a base value", marker)
+	}
+	return readFull(r)
 }
 
-// WriteRaw writes raw bytes without a length prefix.
-func (w *BinaryWriter) WriteRaw(val []byte) error {
-	_, err := w.writer.Write(val)
-	return err
+// ReadCompactWithBase reads a value written by WriteCompact or WriteCompactWithBase,
+// applying readDelta to base when the value was delta encoded.
+func ReadCompactWithBase[T any](r *BinaryReader, base T, readFull func(*BinaryReader) (T, error), readDelta func(r *BinaryReader, base T) (T, error)) (T, error) {
+	var zero T
+	marker, err := r.ReadUint8()
+	if err != nil {
+		return zero, err
+	}
+	switch marker {
+	case compactFull:
+		return readFull(r)
+	case compactDelta:
+		return readDelta(r, base)
+	default:
+		return zero, fmt.Errorf("invalid compact encoding marker %d", marker)
+	}
+}
+
+// ReadLazy skips the next length bytes and returns a closure that decodes them on demand
+// with readFn. The closure seeks back to the sub-record and restores the reader position
+// afterwards, so r must be backed by an io.ReadSeeker.
+func ReadLazy[T any](r *BinaryReader, length uint32, readFn func(*BinaryReader) (T, error)) func() (T, error) {
+	fail := func(err error) func() (T, error) {
+		return func() (T, error) {
+			var zero T
+			return zero, err
+		}
+	}
+	seeker, ok := r.reader.(io.ReadSeeker)
+	if !ok {
+		return fail(errors.New("lazy read requires an io.ReadSeeker-backed BinaryReader"))
+	}
+	end, err := seeker.Seek(int64(length), io.SeekCurrent)
+	if err != nil {
+		return fail(err)
+	}
+	start := end - int64(length)
+	return func() (T, error) {
+		var zero T
+		current, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return zero, err
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return zero, err
+		}
+		val, readErr := readFn(&BinaryReader{reader: io.LimitReader(seeker, int64(length)), order: r.order})
+		if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+			return zero, err
+		}
+		if readErr != nil {
+			return zero, readErr
+		}
+		return val, nil
+	}
 }
 
 // ============ BinaryRef v2 ============
@@ -1159,11 +4010,79 @@ func (idx *UniqueIndex[K, V]) Get(key K) (V, bool) {
 	return val, ok
 }
 
+// Filter returns a snapshot index containing only the entries for which pred returns true.
+func (idx *UniqueIndex[K, V]) Filter(pred func(K, V) bool) *UniqueIndex[K, V] {
+	out := NewUniqueIndex[K, V]()
+	for key, value := range idx.data {
+		if pred(key, value) {
+			out.data[key] = value
+		}
+	}
+	return out
+}
+
+// Delete removes the entry for key, reporting whether it was present.
+func (idx *UniqueIndex[K, V]) Delete(key K) bool {
+	if _, ok := idx.data[key]; !ok {
+		return false
+	}
+	delete(idx.data, key)
+	return true
+}
+
 // Clear removes all entries from the index.
 func (idx *UniqueIndex[K, V]) Clear() {
 	idx.data = make(map[K]V)
 }
 
+// ToGroupIndex groups the entries of idx by the key returned from partition.
+// Values within a group are in no particular order.
+func ToGroupIndex[K comparable, V any, K2 comparable](idx *UniqueIndex[K, V], partition func(K, V) K2) *GroupIndex[K2, V] {
+	out := NewGroupIndex[K2, V]()
+	for key, value := range idx.data {
+		out.Add(partition(key, value), value)
+	}
+	return out
+}
+
+// MapValues returns a new index with the same keys as idx and values transformed by fn.
+// idx itself is not modified.
+func MapValues[K comparable, V, W any](idx *UniqueIndex[K, V], fn func(K, V) W) *UniqueIndex[K, W] {
+	out := &UniqueIndex[K, W]{data: make(map[K]W, len(idx.data))}
+	for key, value := range idx.data {
+		out.data[key] = fn(key, value)
+	}
+	return out
+}
+
+// uniqueIndexFormatVersion is the leading byte of WriteUniqueIndex output.
+const uniqueIndexFormatVersion uint8 = 1
+
+// WriteUniqueIndex serialises idx as a format-version byte, a uint32 entry count, and each
+// key and value encoded by writeKey and writeVal. Entry order is unspecified.
+func WriteUniqueIndex[K comparable, V any](idx *UniqueIndex[K, V], w *BinaryWriter, writeKey func(*BinaryWriter, K) error, writeVal func(*BinaryWriter, V) error) error {
+	if err := w.WriteUint8(uniqueIndexFormatVersion); err != nil {
+		return err
+	}
+	return WriteMap(w, idx.data, writeKey, writeVal)
+}
+
+// ReadUniqueIndex reconstructs an index written by WriteUniqueIndex.
+func ReadUniqueIndex[K comparable, V any](r *BinaryReader, readKey func(*BinaryReader) (K, error), readVal func(*BinaryReader) (V, error)) (*UniqueIndex[K, V], error) {
+	version, err := r.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	if version != uniqueIndexFormatVersion {
+		return nil, fmt.Errorf("unsupported unique index format version %d (expected %d)", version, uniqueIndexFormatVersion)
+	}
+	data, err := ReadMap(r, readKey, readVal)
+	if err != nil {
+		return nil, fmt.Errorf("unique index: %w", err)
+	}
+	return &UniqueIndex[K, V]{data: data}, nil
+}
+
 // GroupIndex provides O(1) lookup for multiple values by key.
 type GroupIndex[K comparable, V any] struct {
 	data map[K][]V
@@ -1179,7 +4098,7 @@ func (idx *GroupIndex[K, V]) Add(key K, value V) {
 	idx.data[key] = append(idx.data[key], value)
 }
 
-// Get retrieves all values for a key.
+// Get retrieves all values for a key in insertion order (or the order left by SortAll).
 func (idx *GroupIndex[K, V]) Get(key K) []V {
 	if vals, ok := idx.data[key]; ok {
 		return vals
@@ -1187,7 +4106,446 @@ func (idx *GroupIndex[K, V]) Get(key K) []V {
 	return nil
 }
 
+// GetSorted returns a copy of key's group stably sorted by less, leaving the index unchanged.
+func (idx *GroupIndex[K, V]) GetSorted(key K, less func(a, b V) bool) []V {
+	vals := slices.Clone(idx.data[key])
+	sort.SliceStable(vals, func(i, j int) bool { return less(vals[i], vals[j]) })
+	return vals
+}
+
+// SortAll stably sorts every group in place, typically once after loading.
+// Subsequent Get calls return the sorted order.
+func (idx *GroupIndex[K, V]) SortAll(less func(a, b V) bool) {
+	for _, vals := range idx.data {
+		sort.SliceStable(vals, func(i, j int) bool { return less(vals[i], vals[j]) })
+	}
+}
+
+// RemoveValue removes the first value in key's group equal to value, reporting whether one was removed.
+func RemoveValue[K, V comparable](idx *GroupIndex[K, V], key K, value V) bool {
+	return idx.RemoveFunc(key, func(v V) bool { return v == value })
+}
+
+// RemoveFunc removes the first value in key's group for which match returns true.
+// The group is rebuilt, so slices previously returned by Get are left unchanged.
+func (idx *GroupIndex[K, V]) RemoveFunc(key K, match func(V) bool) bool {
+	vals, ok := idx.data[key]
+	if !ok {
+		return false
+	}
+	for i, v := range vals {
+		if match(v) {
+			vals = slices.Delete(slices.Clone(vals), i, i+1)
+			if len(vals) == 0 {
+				delete(idx.data, key)
+			} else {
+				idx.data[key] = vals
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// HasAll reports whether every value in values is in key's group. It is true for empty values.
+func HasAll[K, V comparable](idx *GroupIndex[K, V], key K, values []V) bool {
+	group := idx.data[key]
+	for _, want := range values {
+		if !slices.Contains(group, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasAny reports whether at least one value in values is in key's group.
+func HasAny[K, V comparable](idx *GroupIndex[K, V], key K, values []V) bool {
+	group := idx.data[key]
+	for _, want := range values {
+		if slices.Contains(group, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of values in key's group.
+func (idx *GroupIndex[K, V]) Len(key K) int {
+	return len(idx.data[key])
+}
+
+// TotalLen returns the number of values across all groups.
+func (idx *GroupIndex[K, V]) TotalLen() int {
+	total := 0
+	for _, vals := range idx.data {
+		total += len(vals)
+	}
+	return total
+}
+
+// Delete removes the whole group for key, reporting whether it was present.
+func (idx *GroupIndex[K, V]) Delete(key K) bool {
+	if _, ok := idx.data[key]; !ok {
+		return false
+	}
+	delete(idx.data, key)
+	return true
+}
+
+// Expire trims key's group to at most maxCount values by removing the oldest
+// (front) entries, returning how many were removed. A group trimmed to empty is deleted.
+// The group is rebuilt, so slices previously returned by Get are left unchanged.
+func (idx *GroupIndex[K, V]) Expire(key K, maxCount int) int {
+	vals, ok := idx.data[key]
+	if !ok {
+		return 0
+	}
+	maxCount = max(maxCount, 0)
+	removed := len(vals) - maxCount
+	if removed <= 0 {
+		return 0
+	}
+	if maxCount == 0 {
+		delete(idx.data, key)
+		return removed
+	}
+	idx.data[key] = slices.Clone(vals[removed:])
+	return removed
+}
+
+// ExpireAll applies Expire with maxCount to every group, returning the total removed.
+func (idx *GroupIndex[K, V]) ExpireAll(maxCount int) int {
+	total := 0
+	for key := range idx.data {
+		total += idx.Expire(key, maxCount)
+	}
+	return total
+}
+
 // Clear removes all entries from the index.
 func (idx *GroupIndex[K, V]) Clear() {
 	idx.data = make(map[K][]V)
 }
+
+// Diff compares two unique index snapshots, returning keys only in newIdx (added), keys
+// only in oldIdx (removed), and {old, new} value pairs for keys present in both whose
+// value differs (changed). Results are in no particular order.
+func Diff[K comparable, V comparable](oldIdx, newIdx *UniqueIndex[K, V]) (added, removed []K, changed [][2]V) {
+	for key, oldValue := range oldIdx.data {
+		newValue, ok := newIdx.data[key]
+		if !ok {
+			removed = append(removed, key)
+		} else if newValue != oldValue {
+			changed = append(changed, [2]V{oldValue, newValue})
+		}
+	}
+	for key := range newIdx.data {
+		if _, ok := oldIdx.data[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	return added, removed, changed
+}
+
+// DiffGroups compares two group index snapshots, returning added and removed keys and
+// the keys whose group membership changed. Membership ignores order but counts
+// duplicates. Results are in no particular order.
+func DiffGroups[K comparable, V comparable](oldIdx, newIdx *GroupIndex[K, V]) (added, removed, changed []K) {
+	for key, oldValues := range oldIdx.data {
+		newValues, ok := newIdx.data[key]
+		if !ok {
+			removed = append(removed, key)
+		} else if !sameMembers(oldValues, newValues) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range newIdx.data {
+		if _, ok := oldIdx.data[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	return added, removed, changed
+}
+
+func sameMembers[V comparable](a, b []V) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[V]int, len(a))
+	for _, v := range a {
+		counts[v]++
+	}
+	for _, v := range b {
+		if counts[v] == 0 {
+			return false
+		}
+		counts[v]--
+	}
+	return true
+}
+
+// groupIndexFormatVersion is the leading byte of WriteGroupIndex output.
+const groupIndexFormatVersion uint8 = 1
+
+// WriteGroupIndex serialises idx as a format-version byte, a uint32 group count, and for
+// each group its key, a uint32 value count, and the values in group order. Group order is unspecified.
+func WriteGroupIndex[K comparable, V any](idx *GroupIndex[K, V], w *BinaryWriter, writeKey func(*BinaryWriter, K) error, writeVal func(*BinaryWriter, V) error) error {
+	if err := w.WriteUint8(groupIndexFormatVersion); err != nil {
+		return err
+	}
+	if err := w.WriteUint32(uint32(len(idx.data))); err != nil {
+		return err
+	}
+	for key, values := range idx.data {
+		if err := writeKey(w, key); err != nil {
+			return err
+		}
+		if err := w.WriteUint32(uint32(len(values))); err != nil {
+			return err
+		}
+		for _, value := range values {
+			if err := writeVal(w, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReadGroupIndex reconstructs an index written by WriteGroupIndex, preserving each group's order.
+func ReadGroupIndex[K comparable, V any](r *BinaryReader, readKey func(*BinaryReader) (K, error), readVal func(*BinaryReader) (V, error)) (*GroupIndex[K, V], error) {
+	version, err := r.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	if version != groupIndexFormatVersion {
+		return nil, fmt.Errorf("unsupported group index format version %d (expected %d)", version, groupIndexFormatVersion)
+	}
+	groups, err := r.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+	idx := NewGroupIndex[K, V]()
+	for i := uint32(0); i < groups; i++ {
+		key, err := readKey(r)
+		if err != nil {
+			return nil, fmt.Errorf("group index group %d key: %w", i, err)
+		}
+		if _, dup := idx.data[key]; dup {
+			return nil, fmt.Errorf("group index group %d: duplicate key %v", i, key)
+		}
+		count, err := r.ReadUint32()
+		if err != nil {
+			return nil, fmt.Errorf("group index group %d: %w", i, err)
+		}
+		values := make([]V, 0, min(int(count), 1024))
+		for j := uint32(0); j < count; j++ {
+			value, err := readVal(r)
+			if err != nil {
+				return nil, fmt.Errorf("group index group %d value %d: %w", i, j, err)
+			}
+			values = append(values, value)
+		}
+		idx.data[key] = values
+	}
+	return idx, nil
+}
+
+// RangeIndex provides inclusive range lookup over ordered keys.
+// Inserts are buffered and sorted once by Finalize, or lazily on the next query.
+type RangeIndex[K cmp.Ordered, V any] struct {
+	keys   []K
+	values []V
+	sorted bool
+}
+
+// NewRangeIndex creates a new range index.
+func NewRangeIndex[K cmp.Ordered, V any]() *RangeIndex[K, V] {
+	return &RangeIndex[K, V]{sorted: true}
+}
+
+// BuildRangeIndex creates a finalized range index from values using key to extract each key.
+func BuildRangeIndex[K cmp.Ordered, V any](values []V, key func(V) K) *RangeIndex[K, V] {
+	idx := &RangeIndex[K, V]{
+		keys:   make([]K, 0, len(values)),
+		values: make([]V, 0, len(values)),
+	}
+	for _, value := range values {
+		idx.keys = append(idx.keys, key(value))
+		idx.values = append(idx.values, value)
+	}
+	idx.Finalize()
+	return idx
+}
+
+// Insert adds a key-value pair to the index.
+func (idx *RangeIndex[K, V]) Insert(key K, value V) {
+	idx.keys = append(idx.keys, key)
+	idx.values = append(idx.values, value)
+	idx.sorted = false
+}
+
+// Finalize sorts buffered entries by key, keeping insertion order for equal keys.
+func (idx *RangeIndex[K, V]) Finalize() {
+	if idx.sorted {
+		return
+	}
+	order := make([]int, len(idx.keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return cmp.Less(idx.keys[order[a]], idx.keys[order[b]]) })
+	keys := make([]K, len(order))
+	values := make([]V, len(order))
+	for i, j := range order {
+		keys[i] = idx.keys[j]
+		values[i] = idx.values[j]
+	}
+	idx.keys, idx.values, idx.sorted = keys, values, true
+}
+
+// Range returns all values with lo <= key <= hi in key order.
+func (idx *RangeIndex[K, V]) Range(lo, hi K) []V {
+	idx.Finalize()
+	start, _ := slices.BinarySearch(idx.keys, lo)
+	end := sort.Search(len(idx.keys), func(i int) bool { return cmp.Less(hi, idx.keys[i]) })
+	if start >= end {
+		return nil
+	}
+	return append([]V(nil), idx.values[start:end]...)
+}
+
+// Len returns the number of entries in the index.
+func (idx *RangeIndex[K, V]) Len() int {
+	return len(idx.keys)
+}
+
+// Clear removes all entries from the index.
+func (idx *RangeIndex[K, V]) Clear() {
+	idx.keys, idx.values, idx.sorted = nil, nil, true
+}
+
+// BiIndex provides O(1) lookup in both directions over a one-to-one mapping,
+// such as enum id to enum name.
+type BiIndex[K comparable, V comparable] struct {
+	byKey   map[K]V
+	byValue map[V]K
+}
+
+// NewBiIndex creates a new bidirectional index.
+func NewBiIndex[K comparable, V comparable]() *BiIndex[K, V] {
+	return &BiIndex[K, V]{byKey: make(map[K]V), byValue: make(map[V]K)}
+}
+
+// Insert adds a key-value pair. It fails without modifying the index if the key
+// or the value is already mapped to something else; re-inserting the same pair is a no-op.
+func (idx *BiIndex[K, V]) Insert(key K, value V) error {
+	if existing, ok := idx.byValue[value]; ok && existing != key {
+		return fmt.Errorf("value %v is already mapped to key %v", value, existing)
+	}
+	if existing, ok := idx.byKey[key]; ok && existing != value {
+		return fmt.Errorf("key %v is already mapped to value %v", key, existing)
+	}
+	idx.byKey[key] = value
+	idx.byValue[value] = key
+	return nil
+}
+
+// GetByKey retrieves the value mapped to key.
+func (idx *BiIndex[K, V]) GetByKey(key K) (V, bool) {
+	val, ok := idx.byKey[key]
+	return val, ok
+}
+
+// GetByValue retrieves the key mapped to value.
+func (idx *BiIndex[K, V]) GetByValue(value V) (K, bool) {
+	key, ok := idx.byValue[value]
+	return key, ok
+}
+
+// Len returns the number of pairs in the index.
+func (idx *BiIndex[K, V]) Len() int {
+	return len(idx.byKey)
+}
+
+// SyncUniqueIndex is a UniqueIndex guarded by an RWMutex for concurrent access.
+type SyncUniqueIndex[K comparable, V any] struct {
+	mu    sync.RWMutex
+	index *UniqueIndex[K, V]
+}
+
+// NewSyncUniqueIndex creates a new concurrency-safe unique index.
+func NewSyncUniqueIndex[K comparable, V any]() *SyncUniqueIndex[K, V] {
+	return &SyncUniqueIndex[K, V]{index: NewUniqueIndex[K, V]()}
+}
+
+// Insert adds a key-value pair to the index.
+func (idx *SyncUniqueIndex[K, V]) Insert(key K, value V) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.index.Insert(key, value)
+}
+
+// Get retrieves a value by key.
+func (idx *SyncUniqueIndex[K, V]) Get(key K) (V, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.index.Get(key)
+}
+
+// Delete removes the entry for key, reporting whether it was present.
+func (idx *SyncUniqueIndex[K, V]) Delete(key K) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.index.Delete(key)
+}
+
+// Clear removes all entries from the index.
+func (idx *SyncUniqueIndex[K, V]) Clear() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.index.Clear()
+}
+
+// SyncGroupIndex is a GroupIndex guarded by an RWMutex for concurrent access.
+type SyncGroupIndex[K comparable, V any] struct {
+	mu    sync.RWMutex
+	index *GroupIndex[K, V]
+}
+
+// NewSyncGroupIndex creates a new concurrency-safe group index.
+func NewSyncGroupIndex[K comparable, V any]() *SyncGroupIndex[K, V] {
+	return &SyncGroupIndex[K, V]{index: NewGroupIndex[K, V]()}
+}
+
+// Add adds a value to the group for the given key.
+func (idx *SyncGroupIndex[K, V]) Add(key K, value V) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.index.Add(key, value)
+}
+
+// Get returns a copy of all values for a key, so callers never observe later writes.
+func (idx *SyncGroupIndex[K, V]) Get(key K) []V {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	vals := idx.index.Get(key)
+	if vals == nil {
+		return nil
+	}
+	return append([]V(nil), vals...)
+}
+
+// Delete removes the whole group for key, reporting whether it was present.
+func (idx *SyncGroupIndex[K, V]) Delete(key K) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.index.Delete(key)
+}
+
+// Clear removes all entries from the index.
+func (idx *SyncGroupIndex[K, V]) Clear() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.index.Clear()
+}
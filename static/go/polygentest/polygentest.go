@@ -0,0 +1,38 @@
+// Package polygentest provides test helpers for PolyGen generated Go code.
+// It lives in its own package so that importing "testing" stays a test-only dependency.
+package polygentest
+
+import (
+	"bytes"
+	"testing"
+)
+
+// AssertCsvRoundTrip loads csvPath twice with load and fails the test unless
+// compare reports the two results as equal.
+func AssertCsvRoundTrip[T any](t testing.TB, csvPath string, load func(string) (T, error), compare func(T, T) bool) {
+	t.Helper()
+	first, err := load(csvPath)
+	if err != nil {
+		t.Fatalf("first load of %s failed: %v", csvPath, err)
+	}
+	second, err := load(csvPath)
+	if err != nil {
+		t.Fatalf("second load of %s failed: %v", csvPath, err)
+	}
+	if !compare(first, second) {
+		t.Fatalf("loading %s twice produced different results", csvPath)
+	}
+}
+
+// AssertMigrationChain runs migrate over testData from fromVersion to toVersion and fails
+// the test unless the result equals expectedData byte for byte.
+func AssertMigrationChain(t testing.TB, migrate func(fromVersion, toVersion uint16, data []byte) ([]byte, error), fromVersion, toVersion uint16, testData, expectedData []byte) {
+	t.Helper()
+	got, err := migrate(fromVersion, toVersion, testData)
+	if err != nil {
+		t.Fatalf("migration v%d -> v%d failed: %v", fromVersion, toVersion, err)
+	}
+	if !bytes.Equal(got, expectedData) {
+		t.Fatalf("migration v%d -> v%d produced %x, want %x", fromVersion, toVersion, got, expectedData)
+	}
+}
@@ -142,3 +142,163 @@ func TestGeneratedCsvLoaderParsesOptionalAndHistoryEmbedJSON(t *testing.T) {
 		t.Fatalf("history embed JSON list mismatch: %#v", row.History)
 	}
 }
+
+func TestBinaryLengthPrefixedStructRoundTrip(t *testing.T) {
+	createdBy := "system"
+	row := MixedTest{
+		Id:      9,
+		OptTags: []Tag{{Name: "alpha", Color: "red"}},
+		Meta:    &Metadata{CreatedBy: &createdBy, Version: 4},
+	}
+
+	var body bytes.Buffer
+	if err := row.WriteBinary(NewBinaryWriter(&body)); err != nil {
+		t.Fatalf("write mixed binary: %v", err)
+	}
+	var buf bytes.Buffer
+	writer := NewBinaryWriter(&buf)
+	if err := WriteLengthPrefixedStruct(writer, &row); err != nil {
+		t.Fatalf("WriteLengthPrefixedStruct: %v", err)
+	}
+	// A record from a newer writer: the same fields followed by bytes this reader does not know.
+	if err := writer.WriteUint32(uint32(body.Len() + 3)); err != nil {
+		t.Fatalf("write size prefix: %v", err)
+	}
+	if err := writer.WriteRaw(append(body.Bytes(), 1, 2, 3)); err != nil {
+		t.Fatalf("write extended record: %v", err)
+	}
+	if err := writer.WriteUint32(0xCAFE); err != nil {
+		t.Fatalf("write sentinel: %v", err)
+	}
+
+	reader := NewBinaryReader(bytes.NewReader(buf.Bytes()))
+	for i := 0; i < 2; i++ {
+		loaded, err := ReadLengthPrefixedStruct(reader, ReadMixedTestBinary)
+		if err != nil {
+			t.Fatalf("ReadLengthPrefixedStruct #%d: %v", i, err)
+		}
+		if loaded.Id != 9 || len(loaded.OptTags) != 1 || loaded.Meta == nil || loaded.Meta.Version != 4 {
+			t.Fatalf("length-prefixed record #%d mismatch: %#v", i, loaded)
+		}
+	}
+	if sentinel, err := reader.ReadUint32(); err != nil || sentinel != 0xCAFE {
+		t.Fatalf("unknown trailing bytes were not skipped: %x, %v", sentinel, err)
+	}
+
+	truncated := NewBinaryReader(bytes.NewReader(buf.Bytes()[:4+body.Len()-1]))
+	if _, err := ReadLengthPrefixedStruct(truncated, ReadMixedTestBinary); err == nil {
+		t.Fatal("ReadLengthPrefixedStruct should fail on a truncated record")
+	}
+}
+
+func TestBinaryCStringRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewBinaryWriter(&buf)
+	for _, val := range []string{"engine", "", "유니코드"} {
+		if err := writer.WriteCString(val); err != nil {
+			t.Fatalf("WriteCString(%q): %v", val, err)
+		}
+	}
+	if err := writer.WriteCString("bad\x00value"); err == nil {
+		t.Fatal("WriteCString should reject an embedded null byte")
+	}
+
+	reader := NewBinaryReader(bytes.NewReader(buf.Bytes()))
+	for _, want := range []string{"engine", "", "유니코드"} {
+		got, err := reader.ReadCString()
+		if err != nil || got != want {
+			t.Fatalf("ReadCString = %q, %v; want %q", got, err, want)
+		}
+	}
+
+	unterminated := NewBinaryReader(bytes.NewReader([]byte("no terminator")))
+	if _, err := unterminated.ReadCString(); err == nil {
+		t.Fatal("ReadCString should fail on a stream without a terminator")
+	}
+}
+
+func TestBinaryMapRoundTrip(t *testing.T) {
+	writeKey := func(w *BinaryWriter, k string) error { return w.WriteString(k) }
+	writeValue := func(w *BinaryWriter, v int32) error { return w.WriteInt32(v) }
+	readKey := func(r *BinaryReader) (string, error) { return r.ReadString() }
+	readValue := func(r *BinaryReader) (int32, error) { return r.ReadInt32() }
+
+	for _, m := range []map[string]int32{
+		{},
+		{"hp": 100},
+		{"hp": 100, "mp": 50, "strength": -3},
+	} {
+		var buf bytes.Buffer
+		if err := WriteMap(NewBinaryWriter(&buf), m, writeKey, writeValue); err != nil {
+			t.Fatalf("WriteMap(%v): %v", m, err)
+		}
+		got, err := ReadMap(NewBinaryReader(&buf), readKey, readValue)
+		if err != nil {
+			t.Fatalf("ReadMap: %v", err)
+		}
+		if len(got) != len(m) {
+			t.Fatalf("ReadMap = %v, want %v", got, m)
+		}
+		for k, v := range m {
+			if got[k] != v {
+				t.Fatalf("ReadMap[%q] = %d, want %d", k, got[k], v)
+			}
+		}
+	}
+
+	m := map[string]int32{"c": 3, "a": 1, "b": 2}
+	var first, second bytes.Buffer
+	if err := WriteMapSorted(NewBinaryWriter(&first), m, writeKey, writeValue); err != nil {
+		t.Fatalf("WriteMapSorted: %v", err)
+	}
+	if err := WriteMapSorted(NewBinaryWriter(&second), map[string]int32{"b": 2, "a": 1, "c": 3}, writeKey, writeValue); err != nil {
+		t.Fatalf("WriteMapSorted: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatal("WriteMapSorted output should not depend on map iteration order")
+	}
+	reader := NewBinaryReader(&first)
+	if count, err := reader.ReadUint32(); err != nil || count != 3 {
+		t.Fatalf("sorted map count = %d, %v", count, err)
+	}
+	if key, err := reader.ReadString(); err != nil || key != "a" {
+		t.Fatalf("first sorted key = %q, %v", key, err)
+	}
+}
+
+func TestBinaryOptionalStringMapDistinguishesNilAndEmpty(t *testing.T) {
+	cases := []struct {
+		name  string
+		value map[string]string
+		bytes []byte
+	}{
+		{"nil", nil, []byte{0x00}},
+		{"empty", map[string]string{}, []byte{0x01, 0x00, 0x00, 0x00, 0x00}},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := NewBinaryWriter(&buf).WriteOptionalStringMap(c.value); err != nil {
+			t.Fatalf("%s: WriteOptionalStringMap: %v", c.name, err)
+		}
+		if !bytes.Equal(buf.Bytes(), c.bytes) {
+			t.Fatalf("%s: encoded %x, want %x", c.name, buf.Bytes(), c.bytes)
+		}
+		got, err := NewBinaryReader(&buf).ReadOptionalStringMap()
+		if err != nil {
+			t.Fatalf("%s: ReadOptionalStringMap: %v", c.name, err)
+		}
+		if (got == nil) != (c.value == nil) || len(got) != 0 {
+			t.Fatalf("%s: decoded %#v, want %#v", c.name, got, c.value)
+		}
+	}
+
+	var buf bytes.Buffer
+	labels := map[string]string{"en": "Sword", "ko": "검"}
+	if err := NewBinaryWriter(&buf).WriteOptionalStringMap(labels); err != nil {
+		t.Fatalf("WriteOptionalStringMap: %v", err)
+	}
+	got, err := NewBinaryReader(&buf).ReadOptionalStringMap()
+	if err != nil || len(got) != 2 || got["en"] != "Sword" || got["ko"] != "검" {
+		t.Fatalf("ReadOptionalStringMap = %#v, %v", got, err)
+	}
+}
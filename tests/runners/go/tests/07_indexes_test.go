@@ -1,9 +1,16 @@
 package polygen
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestIndexesAndForeignKeyValidation(t *testing.T) {
@@ -275,3 +282,421 @@ func TestForeignKeyValidationRejectsMissingReference(t *testing.T) {
 		t.Fatalf("expected one validation error, got %d: %s", result.ErrorCount(), result.String())
 	}
 }
+
+func TestSyncIndexesConcurrentAccess(t *testing.T) {
+	unique := NewSyncUniqueIndex[uint32, *User]()
+	group := NewSyncGroupIndex[uint32, *Post]()
+	user := &User{Id: 1, Username: "alice", Email: "alice@example.com", DisplayName: "Alice"}
+
+	var wg sync.WaitGroup
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if got, ok := unique.Get(user.Id); ok && got != user {
+					t.Errorf("sync unique index returned %#v", got)
+					return
+				}
+				for _, post := range group.Get(user.Id) {
+					if post.AuthorId != user.Id {
+						t.Errorf("sync group index returned %#v", post)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			unique.Insert(user.Id, user)
+			group.Add(user.Id, &Post{Id: uint32(i), AuthorId: user.Id})
+			if i%100 == 0 {
+				unique.Delete(user.Id)
+				group.Clear()
+			}
+		}
+	}()
+	wg.Wait()
+
+	if got, ok := unique.Get(user.Id); !ok || got != user {
+		t.Fatalf("sync unique index final value = %#v, %v", got, ok)
+	}
+	if got := group.Get(user.Id); len(got) != 99 {
+		t.Fatalf("sync group index final size = %d", len(got))
+	}
+}
+
+func TestUniqueIndexFilter(t *testing.T) {
+	index := NewUniqueIndex[uint32, *User]()
+	alice := &User{Id: 1, Username: "alice", Email: "alice@example.com", DisplayName: "Alice"}
+	bob := &User{Id: 2, Username: "bob", Email: "bob@example.com", DisplayName: "Bob"}
+	index.Insert(alice.Id, alice)
+	index.Insert(bob.Id, bob)
+
+	filtered := index.Filter(func(_ uint32, user *User) bool { return user.Username == "bob" })
+	if got, ok := filtered.Get(bob.Id); !ok || got != bob {
+		t.Fatalf("filtered index missing bob: %#v", got)
+	}
+	if _, ok := filtered.Get(alice.Id); ok {
+		t.Fatalf("filtered index should not contain alice")
+	}
+
+	index.Delete(bob.Id)
+	if _, ok := filtered.Get(bob.Id); !ok {
+		t.Fatalf("filtered index should be a snapshot of the original")
+	}
+
+	empty := index.Filter(func(uint32, *User) bool { return false })
+	if _, ok := empty.Get(alice.Id); ok {
+		t.Fatalf("always-false filter should produce an empty index")
+	}
+}
+
+func TestUniqueIndexMapValues(t *testing.T) {
+	index := NewUniqueIndex[uint32, *User]()
+	alice := &User{Id: 1, Username: "alice", Email: "alice@example.com", DisplayName: "Alice"}
+	bob := &User{Id: 2, Username: "bob", Email: "bob@example.com", DisplayName: "Bob"}
+	index.Insert(alice.Id, alice)
+	index.Insert(bob.Id, bob)
+
+	labels := MapValues(index, func(id uint32, user *User) string {
+		return fmt.Sprintf("%d:%s", id, user.DisplayName)
+	})
+	if got, ok := labels.Get(bob.Id); !ok || got != "2:Bob" {
+		t.Fatalf("mapped index value for bob = %q, %v", got, ok)
+	}
+	if got, ok := labels.Get(alice.Id); !ok || got != "1:Alice" {
+		t.Fatalf("mapped index value for alice = %q, %v", got, ok)
+	}
+
+	if got, ok := index.Get(alice.Id); !ok || got != alice || got.DisplayName != "Alice" {
+		t.Fatalf("original index should be unchanged, got %#v", got)
+	}
+	labels.Delete(alice.Id)
+	if _, ok := index.Get(alice.Id); !ok {
+		t.Fatalf("deleting from the mapped index should not affect the original")
+	}
+}
+
+func TestGroupIndexMembership(t *testing.T) {
+	index := NewGroupIndex[uint32, *Tag]()
+	featured := &Tag{Id: 200, Name: "featured"}
+	news := &Tag{Id: 201, Name: "news"}
+	missing := &Tag{Id: 202, Name: "missing"}
+	index.Add(100, featured)
+	index.Add(100, news)
+
+	if !HasAll(index, 100, nil) {
+		t.Fatalf("HasAll with empty values should be true")
+	}
+	if !HasAll(index, 100, []*Tag{featured, news}) {
+		t.Fatalf("HasAll should find every tag in the group")
+	}
+	if HasAll(index, 100, []*Tag{featured, missing}) {
+		t.Fatalf("HasAll should fail on partial membership")
+	}
+	if !HasAny(index, 100, []*Tag{missing, news}) {
+		t.Fatalf("HasAny should succeed on partial membership")
+	}
+	if HasAny(index, 100, []*Tag{missing}) {
+		t.Fatalf("HasAny should fail when no tag is in the group")
+	}
+	if HasAll(index, 999, []*Tag{featured}) || HasAny(index, 999, []*Tag{featured}) {
+		t.Fatalf("membership checks on a missing key should fail")
+	}
+}
+
+func TestGroupIndexRemove(t *testing.T) {
+	index := NewGroupIndex[uint32, int]()
+	index.Add(1, 10)
+	index.Add(1, 20)
+	index.Add(1, 30)
+	before := index.Get(1)
+
+	if !RemoveValue(index, 1, 10) {
+		t.Fatalf("RemoveValue should remove an existing value")
+	}
+	if RemoveValue(index, 1, 99) || RemoveValue(index, 999, 10) {
+		t.Fatalf("RemoveValue should report false for missing values and keys")
+	}
+	if got := index.Get(1); len(got) != 2 || got[0] != 20 || got[1] != 30 {
+		t.Fatalf("unexpected group after RemoveValue: %v", got)
+	}
+	if len(before) != 3 || before[0] != 10 || before[1] != 20 || before[2] != 30 {
+		t.Fatalf("slice returned by Get before removal changed: %v", before)
+	}
+
+	names := NewGroupIndex[uint32, []string]()
+	names.Add(1, []string{"a"})
+	names.Add(1, []string{"b", "c"})
+	if !names.RemoveFunc(1, func(v []string) bool { return len(v) == 2 }) {
+		t.Fatalf("RemoveFunc should remove a matching value")
+	}
+	if names.RemoveFunc(1, func(v []string) bool { return len(v) == 2 }) {
+		t.Fatalf("RemoveFunc should report false when nothing matches")
+	}
+	if !names.RemoveFunc(1, func(v []string) bool { return v[0] == "a" }) || names.Len(1) != 0 {
+		t.Fatalf("removing the last value should empty the group")
+	}
+}
+
+func TestGroupIndexExpireKeepsEarlierGetResults(t *testing.T) {
+	index := NewGroupIndex[uint32, string]()
+	for _, value := range []string{"a", "b", "c", "d"} {
+		index.Add(1, value)
+		index.Add(2, value)
+	}
+	before := index.Get(1)
+	beforeAll := index.Get(2)
+
+	if removed := index.Expire(1, 2); removed != 2 {
+		t.Fatalf("Expire should remove 2 values, got %d", removed)
+	}
+	if got := index.Get(1); len(got) != 2 || got[0] != "c" || got[1] != "d" {
+		t.Fatalf("unexpected group after Expire: %v", got)
+	}
+	if strings.Join(before, ",") != "a,b,c,d" {
+		t.Fatalf("slice returned by Get before Expire changed: %q", before)
+	}
+
+	if removed := index.ExpireAll(1); removed != 4 {
+		t.Fatalf("ExpireAll should remove 4 values, got %d", removed)
+	}
+	if got := index.Get(2); len(got) != 1 || got[0] != "d" {
+		t.Fatalf("unexpected group after ExpireAll: %v", got)
+	}
+	if strings.Join(beforeAll, ",") != "a,b,c,d" {
+		t.Fatalf("slice returned by Get before ExpireAll changed: %q", beforeAll)
+	}
+}
+
+func TestCsvRowGetUUID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uuids.csv")
+	content := "lower,upper,compact,empty,bad_length,bad_hyphen,bad_hex\n" +
+		"123e4567-e89b-12d3-a456-426614174000,123E4567-E89B-12D3-A456-426614174000,123e4567e89b12d3a456426614174000,," +
+		"123e4567-e89b,123e4567e-89b-12d3-a456-42661417400,123e4567-e89b-12d3-a456-42661417400g\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write uuids.csv: %v", err)
+	}
+	reader, err := NewCsvReader(path)
+	if err != nil {
+		t.Fatalf("open uuids.csv: %v", err)
+	}
+	defer reader.Close()
+	row, err := reader.ReadRow()
+	if err != nil {
+		t.Fatalf("read uuid row: %v", err)
+	}
+
+	want := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	for _, column := range []string{"lower", "upper", "compact"} {
+		got, err := row.GetUUID(column)
+		if err != nil || got != want {
+			t.Fatalf("GetUUID(%q) = %x, %v", column, got, err)
+		}
+	}
+	if got, err := row.GetUUIDPtr("empty"); got != nil || err != nil {
+		t.Fatalf("GetUUIDPtr on empty cell = %v, %v", got, err)
+	}
+	if got, err := row.GetUUIDPtr("compact"); err != nil || got == nil || *got != want {
+		t.Fatalf("GetUUIDPtr = %v, %v", got, err)
+	}
+	for _, column := range []string{"empty", "bad_length", "bad_hyphen", "bad_hex", "missing"} {
+		if _, err := row.GetUUID(column); err == nil {
+			t.Fatalf("GetUUID(%q) should fail", column)
+		}
+	}
+}
+
+func TestCsvReaderStripsUTF8BOM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bom.csv")
+	if err := os.WriteFile(path, []byte("\xEF\xBB\xBFID,Name\n1,alpha\n"), 0644); err != nil {
+		t.Fatalf("write bom.csv: %v", err)
+	}
+	reader, err := NewCsvReader(path)
+	if err != nil {
+		t.Fatalf("open bom.csv: %v", err)
+	}
+	defer reader.Close()
+	row, err := reader.ReadRow()
+	if err != nil {
+		t.Fatalf("read bom row: %v", err)
+	}
+	if got, ok := row.Get("ID"); !ok || got != "1" {
+		t.Fatalf("Get(\"ID\") = %q, %v; BOM should be stripped from the first header", got, ok)
+	}
+	if got := row.GetString("Name"); got != "alpha" {
+		t.Fatalf("GetString(\"Name\") = %q", got)
+	}
+}
+
+func TestCsvReaderContextStopsAfterCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tags.csv")
+	if err := os.WriteFile(path, []byte("Id,Name\n1,a\n2,b\n"), 0o644); err != nil {
+		t.Fatalf("write CSV: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader, err := NewCsvReaderContext(ctx, path)
+	if err != nil {
+		t.Fatalf("NewCsvReaderContext: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := reader.ReadRow(); err != nil {
+		t.Fatalf("first row before cancel: %v", err)
+	}
+	cancel()
+	if row, err := reader.ReadRow(); !errors.Is(err, context.Canceled) || row != nil {
+		t.Fatalf("buffered row after cancel should fail with context.Canceled, got %v, %v", row, err)
+	}
+	if _, err := reader.Peek(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Peek after cancel should fail with context.Canceled, got %v", err)
+	}
+}
+
+func TestLoadJSONChannelStopsWhenConsumerCancels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tags.json")
+	if err := os.WriteFile(path, []byte(`[{"Id":1,"Name":"a"},{"Id":2,"Name":"b"},{"Id":3,"Name":"c"}]`), 0o644); err != nil {
+		t.Fatalf("write JSON: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	items, errs := LoadJSONChannel[Tag](ctx, path, 0)
+	for tag := range items {
+		if tag.Id != 1 {
+			t.Fatalf("unexpected first tag: %+v", tag)
+		}
+		break
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("abandoned stream should report context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("producer goroutine did not exit after cancel")
+	}
+	if _, ok := <-errs; ok {
+		t.Fatal("error channel should be closed after the producer exits")
+	}
+}
+
+func TestMapAllStopsOnFirstMappingError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tags.csv")
+	if err := os.WriteFile(path, []byte("id,name\n1,red\n2,\n3,blue\n"), 0644); err != nil {
+		t.Fatalf("write tags.csv: %v", err)
+	}
+	errEmptyName := errors.New("empty name")
+	mapped := 0
+	mapTag := func(row *CsvRow) (Tag, error) {
+		mapped++
+		if row.GetString("name") == "" {
+			return Tag{}, errEmptyName
+		}
+		return Tag{Id: row.GetUint32("id"), Name: row.GetString("name")}, nil
+	}
+
+	reader, err := NewCsvReader(path)
+	if err != nil {
+		t.Fatalf("open tags.csv: %v", err)
+	}
+	defer reader.Close()
+	tags, err := MapAll(reader, mapTag)
+	if !errors.Is(err, errEmptyName) || tags != nil {
+		t.Fatalf("MapAll = %v, %v; want the mapping error", tags, err)
+	}
+	if mapped != 2 {
+		t.Fatalf("mapFn called %d times, want 2 (stop at the failing row)", mapped)
+	}
+	if next, err := reader.ReadRow(); err != nil || next.GetString("name") != "blue" {
+		t.Fatalf("row after the failing one should remain unread, got %v, %v", next, err)
+	}
+}
+
+func TestCsvReaderSetBoolTokensNilRestoresDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.csv")
+	if err := os.WriteFile(path, []byte("flag\nyes\nja\nno\n"), 0644); err != nil {
+		t.Fatalf("write flags.csv: %v", err)
+	}
+	reader, err := NewCsvReader(path)
+	if err != nil {
+		t.Fatalf("open flags.csv: %v", err)
+	}
+	defer reader.Close()
+	rows, err := reader.ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("read flag rows: %d, %v", len(rows), err)
+	}
+	yes, ja, no := rows[0], rows[1], rows[2]
+
+	reader.SetBoolTokens([]string{"JA"}, nil)
+	if !ja.GetBool("flag") || yes.GetBool("flag") {
+		t.Fatalf("custom truthy set should replace the defaults")
+	}
+	if got, err := no.TryGetBool("flag"); err != nil || got {
+		t.Fatalf("nil falsy should keep the default falsy set, got %v, %v", got, err)
+	}
+
+	reader.SetBoolTokens(nil, nil)
+	if !yes.GetBool("flag") || ja.GetBool("flag") {
+		t.Fatalf("SetBoolTokens(nil, nil) should restore the default truthy set")
+	}
+	if _, err := ja.TryGetBool("flag"); err == nil {
+		t.Fatalf("TryGetBool should reject a value outside the restored defaults")
+	}
+
+	reader.SetBoolTokens([]string{}, []string{})
+	if !yes.GetBool("flag") {
+		t.Fatalf("empty token sets should also restore the defaults")
+	}
+}
+
+func TestCsvRowGetBase64URL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	// 0xfb 0xff 0xbf encodes to "-_-_" in URL-safe base64 and "+/+/" in standard base64.
+	content := "padded,unpadded,standard,empty\n" +
+		"-_-_aGk=,-_-_aGk,+/+/aGk=,\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write tokens.csv: %v", err)
+	}
+	reader, err := NewCsvReader(path)
+	if err != nil {
+		t.Fatalf("open tokens.csv: %v", err)
+	}
+	defer reader.Close()
+	row, err := reader.ReadRow()
+	if err != nil {
+		t.Fatalf("read token row: %v", err)
+	}
+
+	want := []byte{0xfb, 0xff, 0xbf, 'h', 'i'}
+	if got, err := row.GetBase64URL("padded"); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("GetBase64URL = %x, %v", got, err)
+	}
+	if got, err := row.GetBase64URLNoPad("unpadded"); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("GetBase64URLNoPad = %x, %v", got, err)
+	}
+	if got, err := row.GetBase64URL("empty"); err != nil || len(got) != 0 {
+		t.Fatalf("GetBase64URL on empty cell = %x, %v", got, err)
+	}
+
+	_, err = row.GetBase64URL("standard")
+	if err == nil {
+		t.Fatal("GetBase64URL should reject standard base64 input")
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"standard"`) || !strings.Contains(msg, "base64url") {
+		t.Fatalf("error should name the column and encoding, got %q", msg)
+	}
+	if _, err := row.GetBase64URLNoPad("padded"); err == nil {
+		t.Fatal("GetBase64URLNoPad should reject padded input")
+	}
+	if _, err := row.GetBase64URL("missing"); err == nil {
+		t.Fatal("GetBase64URL should fail for a missing column")
+	}
+}
@@ -1,6 +1,12 @@
 package polygen
 
-import "testing"
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func makeComplexStats() Stats {
 	return Stats{
@@ -93,3 +99,150 @@ func TestComplexContainerFieldAndUniqueValidation(t *testing.T) {
 		t.Fatalf("expected unique validation error, got %#v", duplicateResult.Errors)
 	}
 }
+
+func TestConditionalValidationPattern(t *testing.T) {
+	// Recommended usage: state the governing condition and the dependent check
+	// separately, and name the governing field in the error.
+	validate := func(player *Player) *ValidationResult {
+		result := NewValidationResult()
+		inBattle := player.Status == StatusInBattle
+		if !ValidateConditional(inBattle, func() bool { return player.Stats.Hp > 0 }) {
+			result.AddError(ConditionalError("Player", "Stats.Hp", fmt.Sprint(player.Id), "Status", player.Status, "hp must be positive"))
+		}
+		return result
+	}
+
+	fighting := makeComplexPlayer(1, "Fighter", 10)
+	fighting.Status = StatusInBattle
+	fighting.Stats.Hp = 0
+	result := validate(fighting)
+	if !result.ContainsConstraint("Conditional") || !result.ContainsField("Player", "Stats.Hp") {
+		t.Fatalf("expected conditional error, got %s", result.String())
+	}
+
+	resting := makeComplexPlayer(2, "Resting", 10)
+	resting.Stats.Hp = 0
+	if result := validate(resting); !result.IsValid() {
+		t.Fatalf("condition does not hold, expected no errors, got %s", result.String())
+	}
+}
+
+type junitSuiteForTest struct {
+	Name      string `xml:"name,attr"`
+	Tests     int    `xml:"tests,attr"`
+	Failures  int    `xml:"failures,attr"`
+	TestCases []struct {
+		ClassName string `xml:"classname,attr"`
+		Name      string `xml:"name,attr"`
+		Failure   *struct {
+			Message string `xml:"message,attr"`
+		} `xml:"failure"`
+	} `xml:"testcase"`
+	SystemOut string `xml:"system-out"`
+}
+
+func TestValidationResultJUnitXML(t *testing.T) {
+	container := NewSchemaContainer()
+	container.Players.AddRow(makeComplexPlayer(
+		1,
+		"A name that is definitely longer than thirty two chars",
+		101,
+	))
+	result := container.ValidateAll()
+
+	var buf bytes.Buffer
+	if err := result.WriteJUnitXML(&buf, "complex_schema"); err != nil {
+		t.Fatalf("WriteJUnitXML: %v", err)
+	}
+	var suite junitSuiteForTest
+	if err := xml.Unmarshal(buf.Bytes(), &suite); err != nil {
+		t.Fatalf("parse JUnit XML: %v\n%s", err, buf.String())
+	}
+	if suite.Name != "complex_schema" || suite.Tests != 2 || suite.Failures != 2 {
+		t.Fatalf("unexpected suite header: %+v", suite)
+	}
+	for _, tc := range suite.TestCases {
+		if tc.ClassName != "Player" || tc.Failure == nil {
+			t.Fatalf("expected failing Player test case, got %+v", tc)
+		}
+	}
+
+	buf.Reset()
+	if err := NewValidationResult().WriteJUnitXML(&buf, "empty"); err != nil {
+		t.Fatalf("WriteJUnitXML on valid result: %v", err)
+	}
+	var passing junitSuiteForTest
+	if err := xml.Unmarshal(buf.Bytes(), &passing); err != nil {
+		t.Fatalf("parse valid JUnit XML: %v", err)
+	}
+	if passing.Tests != 1 || passing.Failures != 0 || len(passing.TestCases) != 1 || passing.TestCases[0].Failure != nil {
+		t.Fatalf("valid result should be a single passing case: %+v", passing)
+	}
+
+	notes := NewValidationResult()
+	warning := MaxLengthError("Player", "Name", "1", 32, 40)
+	warning.Severity = SeverityWarning
+	notes.AddError(warning)
+	notes.AddError(InfoError("Player", "Level", "1", "level is deprecated"))
+	if notes.ErrorCount() != 0 || !notes.IsValid() || notes.WarningCount() != 1 || notes.InfoCount() != 1 {
+		t.Fatalf("warnings and info should not count as errors: %s", notes.String())
+	}
+	buf.Reset()
+	if err := notes.WriteJUnitXML(&buf, "notes"); err != nil {
+		t.Fatalf("WriteJUnitXML on warning-only result: %v", err)
+	}
+	var noted junitSuiteForTest
+	if err := xml.Unmarshal(buf.Bytes(), &noted); err != nil {
+		t.Fatalf("parse warning-only JUnit XML: %v", err)
+	}
+	if noted.Failures != 0 || len(noted.TestCases) != 1 || noted.TestCases[0].Failure != nil {
+		t.Fatalf("warnings and info should not fail the suite: %+v", noted)
+	}
+	if !strings.Contains(noted.SystemOut, "[Warning]") || !strings.Contains(noted.SystemOut, "[Info]") {
+		t.Fatalf("warnings and info should be listed in system-out: %q", noted.SystemOut)
+	}
+
+	capped := NewValidationResultCapped(1)
+	capped.AddError(warning)
+	capped.AddError(MaxLengthError("Player", "Name", "2", 32, 41))
+	capped.AddError(MaxLengthError("Player", "Name", "3", 32, 42))
+	if capped.IsValid() {
+		t.Fatalf("capped result with suppressed errors should be invalid")
+	}
+	buf.Reset()
+	if err := capped.WriteJUnitXML(&buf, "capped"); err != nil {
+		t.Fatalf("WriteJUnitXML on capped result: %v", err)
+	}
+	var cappedSuite junitSuiteForTest
+	if err := xml.Unmarshal(buf.Bytes(), &cappedSuite); err != nil {
+		t.Fatalf("parse capped JUnit XML: %v", err)
+	}
+	if cappedSuite.Tests != 1 || cappedSuite.Failures != 1 || cappedSuite.TestCases[0].Name != "suppressed" || cappedSuite.TestCases[0].Failure == nil {
+		t.Fatalf("suppressed errors should fail the suite: %+v", cappedSuite)
+	}
+}
+
+func TestValidateURLScheme(t *testing.T) {
+	cases := []struct {
+		value string
+		want  bool
+	}{
+		{"https://example.com/assets/icon.png", true},
+		{"HTTPS://example.com", true},
+		{"http://example.com/assets/icon.png", false},
+		{"ftp://example.com/assets/icon.png", false},
+		{"/assets/icon.png", false},
+		{"https:/assets/icon.png", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := ValidateURLScheme(c.value, "https"); got != c.want {
+			t.Fatalf("ValidateURLScheme(%q, \"https\") = %v, want %v", c.value, got, c.want)
+		}
+	}
+
+	err := URLSchemeError("Player", "AvatarUrl", "1", "https", "http://example.com")
+	if err.ConstraintType != "URLScheme" || err.Severity != SeverityError {
+		t.Fatalf("unexpected URLSchemeError: %+v", err)
+	}
+}